@@ -25,6 +25,13 @@ type ResourcesConfig struct {
 	SetupChassis *SetupChassis `yaml:"setupChassis"`
 }
 
+// ConfigDiff describes a setting whose current value differs from the desired configuration.
+type ConfigDiff struct {
+	Field   string
+	Current string
+	Desired string
+}
+
 // BladeBmcAccount declares attributes for a Blade BMC user to be managed through the chassis.
 type BladeBmcAccount struct {
 	Name     string `yaml:"name"`
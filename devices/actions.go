@@ -0,0 +1,55 @@
+package devices
+
+// Actions are the names of the action methods a provider may implement, the ones
+// available on a given connection are returned by its SupportedActions
+var Actions = []string{
+	"AddBladeBmcAdmin",
+	"CancelJob",
+	"ClearJobQueue",
+	"ClearSEL",
+	"CommitPending",
+	"ConsoleStream",
+	"CreateUser",
+	"CreateUserInSlot",
+	"DeleteJob",
+	"DeleteUser",
+	"IsOn",
+	"IsOnBlade",
+	"ModBladeBmcUser",
+	"MountISO",
+	"PowerCycle",
+	"PowerCycleBlade",
+	"PowerCycleBmc",
+	"PowerCycleBmcBlade",
+	"PowerCycleWith",
+	"PowerOff",
+	"PowerOffBlade",
+	"PowerOffForce",
+	"PowerOffSlot",
+	"PowerOffSoft",
+	"PowerOn",
+	"PowerOnBlade",
+	"PowerOnSlot",
+	"PressPowerButton",
+	"PxeOnce",
+	"PxeOnceBlade",
+	"RemoveBladeBmcUser",
+	"ReseatBlade",
+	"ResetConfig",
+	"ResetRecoveryCounters",
+	"SOL",
+	"SetBMCTime",
+	"SetBootDevice",
+	"SetBootMode",
+	"SetDynamicPower",
+	"SetFlexAddressState",
+	"SetIdentifyLED",
+	"SetIpmiOverLan",
+	"SetNTP",
+	"SetNetwork",
+	"SetPowerRestorePolicy",
+	"UnmountISO",
+	"UpdateFirmware",
+	"UpdateFirmwareBmcBlade",
+	"UpdatePassword",
+}
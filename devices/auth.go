@@ -0,0 +1,5 @@
+package devices
+
+// TokenProvider returns a fresh session token to authenticate against the bmc,
+// it's used to integrate with secret brokers issuing short-lived credentials
+type TokenProvider func() (token string, err error)
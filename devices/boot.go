@@ -0,0 +1,11 @@
+package devices
+
+// BootMode defines the firmware interface used to boot a machine
+type BootMode string
+
+const (
+	// BootModeUEFI boots the machine using UEFI
+	BootModeUEFI BootMode = "UEFI"
+	// BootModeLegacy boots the machine using the legacy bios
+	BootModeLegacy BootMode = "Legacy"
+)
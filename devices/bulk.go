@@ -0,0 +1,45 @@
+package devices
+
+import (
+	"fmt"
+	"sync"
+)
+
+// BulkPowerCycle power cycles the given servers running at most concurrency of them at once, below
+// one they are power cycled one by one. A failure doesn't stop the others, every server gets an entry
+// in the returned map, nil for the ones that were power cycled
+func BulkPowerCycle(bmcs []PowerController, concurrency int) (results map[PowerController]error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results = make(map[PowerController]error, len(bmcs))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	queue := make(chan PowerController)
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for bmc := range queue {
+				status, err := bmc.PowerCycle()
+				if err == nil && !status {
+					err = fmt.Errorf("the bmc didn't acknowledge the power cycle")
+				}
+
+				mu.Lock()
+				results[bmc] = err
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, bmc := range bmcs {
+		queue <- bmc
+	}
+	close(queue)
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,78 @@
+package devices
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeServer power cycles successfully unless it's given an error, recording how many
+// servers are being power cycled at the same time
+type fakeServer struct {
+	name    string
+	status  bool
+	err     error
+	running *int32
+	peak    *int32
+}
+
+func (f *fakeServer) PowerCycle() (bool, error) {
+	running := atomic.AddInt32(f.running, 1)
+	defer atomic.AddInt32(f.running, -1)
+
+	for {
+		peak := atomic.LoadInt32(f.peak)
+		if running <= peak || atomic.CompareAndSwapInt32(f.peak, peak, running) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	return f.status, f.err
+}
+
+func (f *fakeServer) PowerOn() (bool, error)       { return true, nil }
+func (f *fakeServer) PowerOff() (bool, error)      { return true, nil }
+func (f *fakeServer) IsOn() (bool, error)          { return true, nil }
+func (f *fakeServer) PowerCycleBmc() (bool, error) { return true, nil }
+
+func TestBulkPowerCycle(t *testing.T) {
+	concurrency := 3
+	var running, peak int32
+
+	failure := fmt.Errorf("connection refused")
+	bmcs := []PowerController{}
+	expected := map[PowerController]bool{}
+	for n := 0; n < 10; n++ {
+		server := &fakeServer{name: fmt.Sprintf("server%d", n), status: true, running: &running, peak: &peak}
+		switch n % 4 {
+		case 1:
+			server.status, server.err = false, failure
+		case 2:
+			server.status = false
+		}
+		bmcs = append(bmcs, server)
+		expected[server] = server.status
+	}
+
+	results := BulkPowerCycle(bmcs, concurrency)
+
+	if len(results) != len(bmcs) {
+		t.Fatalf("Expected answer %v results: found %v", len(bmcs), len(results))
+	}
+
+	for bmc, succeeded := range expected {
+		err, found := results[bmc]
+		if !found || (err == nil) != succeeded {
+			t.Errorf("Expected answer for %s %v: found %v", bmc.(*fakeServer).name, succeeded, err)
+		}
+		if bmc.(*fakeServer).err != nil && err != bmc.(*fakeServer).err {
+			t.Errorf("Expected answer for %s %v: found %v", bmc.(*fakeServer).name, bmc.(*fakeServer).err, err)
+		}
+	}
+
+	if peak > int32(concurrency) || peak < 2 {
+		t.Errorf("Expected answer 2 to %d servers power cycled at once: found %d", concurrency, peak)
+	}
+}
@@ -41,4 +41,58 @@ const (
 	Ilo5 = "iLO5"
 	//AtenSM is the constant for AtenSM bmc
 	AtenSM = "AtenSM"
+
+	// SSH constants
+
+	// EncodingRaw keeps the ssh command output exactly as sent by the device
+	EncodingRaw = ""
+	// EncodingUTF8 strips invalid utf-8 sequences and control characters from the ssh command output
+	EncodingUTF8 = "utf8"
+	// EncodingLatin1 decodes the ssh command output from latin1 (ISO-8859-1) into utf-8
+	EncodingLatin1 = "latin1"
+
+	// Transport constants
+
+	// TransportRedfish is the redfish api served over https
+	TransportRedfish = "redfish"
+	// TransportSSH is the cli of the bmc, e.g. racadm
+	TransportSSH = "ssh"
+	// TransportHTTPS is the web interface of the bmc, used to detect the vendor
+	TransportHTTPS = "https"
+	// TransportIPMI is ipmi over lan (rmcp+)
+	TransportIPMI = "ipmi"
+
+	// Power state constants
+
+	// PowerStateOn is reported when the machine is powered on
+	PowerStateOn = "on"
+	// PowerStateOff is reported when the machine is powered off
+	PowerStateOff = "off"
+	// PowerStatePoweringOn is reported while the machine is powering on
+	PowerStatePoweringOn = "poweringOn"
+	// PowerStatePoweringOff is reported while the machine is powering off
+	PowerStatePoweringOff = "poweringOff"
+	// PowerStateUnknown is reported when the bmc printed a power state we don't know
+	PowerStateUnknown = "unknown"
+
+	// Port constants
+
+	// SSHPort is the default port of the ssh transport
+	SSHPort = 22
+	// IPMIPort is the default port of the ipmi (rmcp+) transport
+	IPMIPort = 623
+	// RedfishPort is the default port of the redfish and https transports
+	RedfishPort = 443
+)
+
+var (
+	// Legacy SSH algorithms, required to connect to old firmwares (iLO3, iDRAC6 era)
+	// that only speak algorithms disabled by default in modern ssh clients
+
+	// LegacySSHCiphers is the list of ciphers offered when connecting to old firmwares
+	LegacySSHCiphers = []string{"aes128-ctr", "aes192-ctr", "aes256-ctr", "aes128-cbc", "3des-cbc", "arcfour256", "arcfour128", "arcfour"}
+	// LegacySSHKeyExchanges is the list of key exchanges offered when connecting to old firmwares
+	LegacySSHKeyExchanges = []string{"curve25519-sha256@libssh.org", "ecdh-sha2-nistp256", "diffie-hellman-group14-sha1", "diffie-hellman-group1-sha1"}
+	// LegacySSHMACs is the list of macs offered when connecting to old firmwares
+	LegacySSHMACs = []string{"hmac-sha2-256", "hmac-sha1", "hmac-sha1-96"}
 )
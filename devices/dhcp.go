@@ -0,0 +1,12 @@
+package devices
+
+import "time"
+
+// DHCPLease tells whether the bmc address was assigned by dhcp, Server and Expires
+// are only filled when the bmc exposes them
+type DHCPLease struct {
+	Enabled   bool
+	IPAddress string
+	Server    string
+	Expires   time.Time
+}
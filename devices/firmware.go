@@ -0,0 +1,8 @@
+package devices
+
+// FirmwareComponent represents the firmware installed in a hardware component
+type FirmwareComponent struct {
+	Component       string
+	Version         string
+	UpdateAvailable bool
+}
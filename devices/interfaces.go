@@ -25,6 +25,7 @@ type Bmc interface {
 	PowerCycleBmc() (status bool, err error)
 	PowerCycle() (status bool, err error)
 	Serial() (string, error)
+	ServiceTag() (string, error)
 	Status() (string, error)
 	TempC() (int, error)
 	Vendor() string
@@ -75,3 +76,31 @@ type BmcChassis interface {
 	UpdateCredentials(string, string)
 	Vendor() string
 }
+
+// SlotChassis represents an enclosure controller powering the many servers in its slots,
+// unlike Bmc which controls a single host. BmcChassis providers implement it too
+type SlotChassis interface {
+	PowerOnSlot(int) (bool, error)
+	PowerOffSlot(int) (bool, error)
+	SlotPowerState(int) (string, error)
+	Slots() ([]*SlotInfo, error)
+}
+
+// PowerController represents the power actions shared by the servers of every vendor, it lets
+// the callers managing different vendors work with them without knowing the concrete types
+type PowerController interface {
+	PowerCycle() (bool, error)
+	PowerOn() (bool, error)
+	PowerOff() (bool, error)
+	IsOn() (bool, error)
+	PowerCycleBmc() (bool, error)
+}
+
+// Inventory represents the facts collected from the servers of every vendor, it lets the fleet
+// scanners read them without knowing the concrete types. Bmc providers implement it too
+type Inventory interface {
+	Serial() (string, error)
+	BiosVersion() (string, error)
+	BmcVersion() (string, error)
+	PowerState() (string, error)
+}
@@ -0,0 +1,13 @@
+package devices
+
+// IntrusionStatus is the state of the chassis intrusion sensor
+type IntrusionStatus string
+
+const (
+	// IntrusionClosed means the chassis cover is closed
+	IntrusionClosed IntrusionStatus = "Closed"
+	// IntrusionOpen means the chassis cover is open or was opened
+	IntrusionOpen IntrusionStatus = "Open"
+	// IntrusionUnknown means the sensor is fitted but has no reading
+	IntrusionUnknown IntrusionStatus = "Unknown"
+)
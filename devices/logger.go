@@ -0,0 +1,13 @@
+package devices
+
+// Logger receives the commands run on the bmc and their output as alternating keys and values,
+// e.g. Log("host", "10.0.0.1:22", "command", "racadm getsysinfo"). The secrets are masked
+type Logger interface {
+	Log(keyvals ...interface{})
+}
+
+// NopLogger discards everything, it's the logger used when none is set
+type NopLogger struct{}
+
+// Log does nothing
+func (NopLogger) Log(keyvals ...interface{}) {}
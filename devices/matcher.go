@@ -0,0 +1,4 @@
+package devices
+
+// SuccessMatcher tells if the output of a command reports it succeeded
+type SuccessMatcher func(output string) bool
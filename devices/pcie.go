@@ -0,0 +1,11 @@
+package devices
+
+// PCIeDevice represents a device attached to the pci express bus
+type PCIeDevice struct {
+	Slot        string
+	VendorID    string
+	DeviceID    string
+	Description string
+	LinkWidth   string
+	LinkSpeed   string
+}
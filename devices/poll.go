@@ -0,0 +1,10 @@
+package devices
+
+import "time"
+
+// PollOptions defines how the wait methods poll the bmc, Interval is the delay before the
+// second attempt and grows from there while MaxAttempts, when not zero, bounds the attempts
+type PollOptions struct {
+	Interval    time.Duration
+	MaxAttempts int
+}
@@ -0,0 +1,24 @@
+package devices
+
+// ResetType defines how a device is reset when power cycling it
+type ResetType string
+
+const (
+	// ResetWarm restarts the machine without removing its power, the default for PowerCycle
+	ResetWarm ResetType = "warm"
+	// ResetCold removes the power from the machine before powering it on again,
+	// clearing hardware states a warm reset keeps. Supported by iDRAC8, iDRAC9, iLO and SupermicroX10
+	ResetCold ResetType = "cold"
+)
+
+// PowerRestorePolicy defines the power state of a device once the AC power comes back
+type PowerRestorePolicy string
+
+const (
+	// PowerRestoreAlwaysOn powers the device on when the AC power is restored
+	PowerRestoreAlwaysOn PowerRestorePolicy = "AlwaysOn"
+	// PowerRestoreAlwaysOff keeps the device off when the AC power is restored
+	PowerRestoreAlwaysOff PowerRestorePolicy = "AlwaysOff"
+	// PowerRestoreLast returns the device to the state it was in when the AC power was lost
+	PowerRestoreLast PowerRestorePolicy = "RestoreLast"
+)
@@ -7,3 +7,16 @@ type Psu struct {
 	PowerKw    float64
 	Status     string
 }
+
+// PowerSupply represents the detailed status of a power supply, Redundant is false
+// whenever the device would lose power if this power supply failed. Present is false
+// for an empty slot the bmc lists
+type PowerSupply struct {
+	ID           string
+	Model        string
+	Status       string
+	OutputWatts  int
+	InputVoltage int
+	Redundant    bool
+	Present      bool
+}
@@ -0,0 +1,10 @@
+package devices
+
+import "time"
+
+// RecoveryStats holds the automatic server recovery (ASR) and NMI events posted by the bmc
+type RecoveryStats struct {
+	ASRCount int
+	LastASR  time.Time
+	NMICount int
+}
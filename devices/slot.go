@@ -0,0 +1,11 @@
+package devices
+
+// SlotInfo describes a server slot of a blade enclosure
+type SlotInfo struct {
+	Position   int
+	Name       string
+	Serial     string
+	Model      string
+	PowerState string
+	Status     string
+}
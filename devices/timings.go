@@ -0,0 +1,16 @@
+package devices
+
+import "time"
+
+// Timings is the breakdown of the time spent running the last ssh command against a bmc
+type Timings struct {
+	// Dial is the time taken to open the tcp connection
+	Dial time.Duration
+	// Auth is the time taken by the ssh handshake and authentication
+	Auth time.Duration
+	// Command is the time taken to run the last command
+	Command time.Duration
+	// Reused is true when the command ran over a connection already used by previous commands,
+	// meaning Dial and Auth weren't paid by it
+	Reused bool
+}
@@ -0,0 +1,17 @@
+package devices
+
+const (
+	// VirtualMediaCD is a virtual cd/dvd drive
+	VirtualMediaCD = "CD"
+	// VirtualMediaFloppy is a virtual floppy drive
+	VirtualMediaFloppy = "Floppy"
+	// VirtualMediaUSB is a virtual usb key
+	VirtualMediaUSB = "USB"
+)
+
+// VirtualMediaDevice is a virtual media slot of the bmc and the image attached to it, if any
+type VirtualMediaDevice struct {
+	Type     string
+	Attached bool
+	ImageURL string
+}
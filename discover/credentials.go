@@ -0,0 +1,71 @@
+package discover
+
+import (
+	"github.com/bmc-toolbox/bmclib/devices"
+	"github.com/bmc-toolbox/bmclib/errors"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Credential is a username and password pair used to login to a bmc
+type Credential struct {
+	Username string
+	Password string
+}
+
+// DefaultCredentials are the factory credentials attempted per vendor by UsingDefaultCredentials,
+// the sets can be replaced or extended to match the defaults used across the fleet. HP ships the
+// iLOs with a random password printed on the pull tab, so there is no default set for it out of the box
+var DefaultCredentials = map[string][]Credential{
+	devices.Dell:       {{Username: "root", Password: "calvin"}},
+	devices.HP:         {},
+	devices.Supermicro: {{Username: "ADMIN", Password: "ADMIN"}},
+}
+
+// UsingDefaultCredentials detects the vendor of the given host and tries to login with its default
+// credentials, reporting whether any of them is accepted. Nothing is changed on the device
+func UsingDefaultCredentials(host string) (match bool, vendor string, err error) {
+	bmcConnection, err := ScanAndConnect(host, "", "")
+	if err != nil {
+		return match, vendor, err
+	}
+
+	vendor = connectionVendor(bmcConnection)
+	for _, credential := range DefaultCredentials[vendor] {
+		log.WithFields(log.Fields{"step": "UsingDefaultCredentials", "host": host, "vendor": vendor, "username": credential.Username}).Debug("trying default credentials")
+
+		bmcConnection, err = ScanAndConnect(host, credential.Username, credential.Password)
+		if err != nil {
+			return match, vendor, err
+		}
+
+		conn, ok := bmcConnection.(interface {
+			CheckCredentials() error
+			Close() error
+		})
+		if !ok {
+			return match, vendor, errors.ErrFeatureUnavailable
+		}
+
+		err = conn.CheckCredentials()
+		if err == errors.ErrLoginFailed {
+			continue
+		} else if err != nil {
+			return match, vendor, err
+		}
+
+		conn.Close()
+		return true, vendor, nil
+	}
+
+	return false, vendor, nil
+}
+
+// connectionVendor returns the vendor of a connection returned by ScanAndConnect
+func connectionVendor(bmcConnection interface{}) (vendor string) {
+	if conn, ok := bmcConnection.(interface{ Vendor() string }); ok {
+		return conn.Vendor()
+	}
+
+	return devices.Unknown
+}
@@ -1,10 +1,14 @@
 package discover
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
+	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/bmc-toolbox/bmclib/errors"
@@ -24,8 +28,94 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-// ScanAndConnect will scan the bmc trying to learn the device type and return a working connection
+// ScanAndConnect will scan the bmc trying to learn the device type and return a working connection,
+// enclosure controllers are returned as devices.BmcChassis and devices.SlotChassis, servers as devices.Bmc.
+// A *errors.ConnectError is returned when the host doesn't answer over https, its Reason tells apart
+// a host that is unreachable from one only answering over ssh or ipmi
 func ScanAndConnect(host string, username string, password string) (bmcConnection interface{}, err error) {
+	bmcConnection, err = scan(host, username, password)
+	if _, ok := err.(*url.Error); ok {
+		return bmcConnection, connectError(host, err)
+	}
+
+	return bmcConnection, err
+}
+
+// Connect works as ScanAndConnect and also verifies the credentials, a *errors.ConnectError
+// with errors.ErrAuthentication as Reason is returned when the bmc rejects them
+func Connect(host string, username string, password string) (bmcConnection interface{}, err error) {
+	bmcConnection, err = ScanAndConnect(host, username, password)
+	if err != nil {
+		return bmcConnection, err
+	}
+
+	conn, ok := bmcConnection.(interface{ CheckCredentials() error })
+	if !ok {
+		return bmcConnection, errors.ErrFeatureUnavailable
+	}
+
+	err = conn.CheckCredentials()
+	if err == errors.ErrLoginFailed || err == errors.Err401Redfish {
+		return bmcConnection, &errors.ConnectError{Host: host, Reason: errors.ErrAuthentication, Attempts: map[string]error{devices.TransportHTTPS: err}}
+	}
+
+	return bmcConnection, err
+}
+
+// New works as ScanAndConnect for the servers, returning the provider detected behind devices.Bmc so the
+// callers managing different vendors don't need to know the concrete types. The enclosure controllers
+// are rejected with errors.ErrVendorNotSupported, ScanAndConnect returns them
+func New(host string, username string, password string) (bmc devices.Bmc, err error) {
+	bmcConnection, err := ScanAndConnect(host, username, password)
+	if err != nil {
+		return bmc, err
+	}
+
+	bmc, ok := bmcConnection.(devices.Bmc)
+	if !ok {
+		return bmc, fmt.Errorf("%s is an enclosure controller: %w", host, errors.ErrVendorNotSupported)
+	}
+
+	return bmc, err
+}
+
+// connectError probes the other management ports of a host that didn't answer over https
+// to tell whether it's unreachable or speaks a transport the vendor can't be detected with
+func connectError(host string, err error) error {
+	hostname := host
+	if h, _, e := net.SplitHostPort(host); e == nil {
+		hostname = h
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	status, _ := ProbePorts(ctx, hostname)
+
+	connectErr := &errors.ConnectError{Host: host, Reason: errors.ErrUnreachable, Attempts: map[string]error{devices.TransportHTTPS: err}}
+	connectErr.Attempts[devices.TransportSSH] = portError(status.SSH, devices.SSHPort)
+	connectErr.Attempts[devices.TransportIPMI] = portError(status.IPMI, devices.IPMIPort)
+	if status.SSH || status.IPMI {
+		connectErr.Reason = errors.ErrNoSupportedTransport
+	}
+
+	if status.SSH {
+		connectErr.Vendor, _, _ = SSHVendor(ctx, net.JoinHostPort(hostname, strconv.Itoa(devices.SSHPort)))
+	}
+
+	return connectErr
+}
+
+// portError returns the attempt result of a probed port
+func portError(open bool, port int) error {
+	if open {
+		return nil
+	}
+
+	return fmt.Errorf("port %d didn't answer", port)
+}
+
+// scan learns the device type from the pages served over https and returns the matching provider
+func scan(host string, username string, password string) (bmcConnection interface{}, err error) {
 	log.WithFields(log.Fields{"step": "ScanAndConnect", "host": host}).Debug("detecting vendor")
 
 	client, err := httpclient.Build()
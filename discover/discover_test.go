@@ -1,18 +1,27 @@
 package discover
 
 import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	goerrors "errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/bmc-toolbox/bmclib/devices"
+	"github.com/bmc-toolbox/bmclib/errors"
 	"github.com/bmc-toolbox/bmclib/providers/dell/idrac8"
 	"github.com/bmc-toolbox/bmclib/providers/dell/idrac9"
 	"github.com/bmc-toolbox/bmclib/providers/hp/ilo"
 	"github.com/bmc-toolbox/bmclib/providers/supermicro/supermicrox10"
 
 	"github.com/spf13/viper"
+	"golang.org/x/crypto/ssh"
 )
 
 var (
@@ -193,3 +202,310 @@ func TestFindISupermicroX10(t *testing.T) {
 
 	tearDown()
 }
+
+func TestNew(t *testing.T) {
+	_, err := setup(answers["IDrac8"])
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+	defer tearDown()
+
+	bmc, err := New(strings.TrimPrefix(server.URL, "https://"), "super", "test")
+	if err != nil {
+		t.Fatalf("Found errors calling New %v", err)
+	}
+
+	if answer, ok := bmc.(*idrac8.IDrac8); !ok {
+		t.Errorf("Expected answer %T: found %T", &idrac8.IDrac8{}, answer)
+	}
+}
+
+func TestSSHVendor(t *testing.T) {
+	tt := []struct {
+		banner string
+		vendor string
+		err    error
+	}{
+		{"SSH-2.0-mpSSH_0.2.1\r\n", devices.HP, nil},
+		{"SSH-2.0-iDRAC_SSH\r\n", devices.Dell, nil},
+		{"SSH-2.0-OpenSSH_7.4\r\n", "", errors.ErrVendorUnknown},
+	}
+
+	for _, tc := range tt {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Found errors during the test setup %v", err)
+		}
+
+		go func(banner string) {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			conn.Write([]byte(banner))
+		}(tc.banner)
+
+		vendor, _, err := SSHVendor(context.Background(), listener.Addr().String())
+		listener.Close()
+		if vendor != tc.vendor || err != tc.err {
+			t.Errorf("Expected answer %v %v: found %v %v", tc.vendor, tc.err, vendor, err)
+		}
+	}
+}
+
+// serveShell runs a ssh server printing the given banner on the shell of the first session,
+// the session is kept open as the clis of the bmcs do unless hangup is set
+func serveShell(t *testing.T, banner string, hangup bool) (listener net.Listener) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			return nil, nil
+		},
+	}
+	config.AddHostKey(signer)
+
+	listener, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		_, chans, reqs, err := ssh.NewServerConn(conn, config)
+		if err != nil {
+			return
+		}
+		go ssh.DiscardRequests(reqs)
+
+		for newChannel := range chans {
+			channel, requests, err := newChannel.Accept()
+			if err != nil {
+				return
+			}
+
+			for req := range requests {
+				req.Reply(req.Type == "pty-req" || req.Type == "shell", nil)
+				if req.Type != "shell" {
+					continue
+				}
+
+				channel.Write([]byte(banner))
+				if hangup {
+					channel.Close()
+				}
+			}
+		}
+	}()
+
+	return listener
+}
+
+func TestDetectVendor(t *testing.T) {
+	tt := []struct {
+		name   string
+		banner string
+		hangup bool
+		vendor string
+	}{
+		{name: "iLO", banner: "User:Administrator logged-in to ILOCZ3629FY8B.example.com(10.0.0.2 / FE80::1)\r\n\r\niLO 4 Advanced 2.54 at  Jun 15 2017\r\nServer Name: bbmi\r\nServer Power: On\r\n\r\n</>hpiLO-> ", vendor: "hp-ilo"},
+		{name: "iDRAC8", banner: "/admin1-> ", vendor: "dell-idrac8"},
+		{name: "unknown", banner: "Welcome\r\n$ ", hangup: true, vendor: "unknown"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			listener := serveShell(t, tc.banner, tc.hangup)
+			defer listener.Close()
+
+			vendor, err := DetectVendor(listener.Addr().String(), "super", "test")
+			if err != nil {
+				t.Fatalf("Found errors calling DetectVendor %v", err)
+			}
+
+			if vendor != tc.vendor {
+				t.Errorf("Expected answer %v: found %v", tc.vendor, vendor)
+			}
+		})
+	}
+}
+
+func TestPowerController(t *testing.T) {
+	expectedAnswer := []string{"*idrac8.IDrac8", "*ilo.Ilo"}
+
+	var bmcs []devices.PowerController
+	for _, vendor := range []string{"IDrac8", "Ilo"} {
+		bmc, err := setup(answers[vendor])
+		if err != nil {
+			t.Fatalf("Found errors during the test setup %v", err)
+		}
+		tearDown()
+
+		controller, ok := bmc.(devices.PowerController)
+		if !ok {
+			t.Fatalf("Expected %T to implement devices.PowerController", bmc)
+		}
+		bmcs = append(bmcs, controller)
+	}
+
+	for pos, bmc := range bmcs {
+		if answer := fmt.Sprintf("%T", bmc); answer != expectedAnswer[pos] {
+			t.Errorf("Expected answer %v: found %v", expectedAnswer[pos], answer)
+		}
+	}
+}
+
+func TestProbePorts(t *testing.T) {
+	expectedAnswer := PortStatus{SSH: false, Redfish: true, IPMI: true}
+
+	https, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+	defer https.Close()
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+	closed.Close()
+
+	ipmi, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+	defer ipmi.Close()
+
+	go func() {
+		ping := make([]byte, 64)
+		_, addr, err := ipmi.ReadFrom(ping)
+		if err != nil {
+			return
+		}
+		pong := []byte{0x06, 0x00, 0xff, 0x06, 0x00, 0x00, 0x11, 0xbe, 0x40, 0x00, 0x00, 0x10}
+		ipmi.WriteTo(pong, addr)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	answer, err := probePorts(ctx, "127.0.0.1", closed.Addr().(*net.TCPAddr).Port, https.Addr().(*net.TCPAddr).Port, ipmi.LocalAddr().(*net.UDPAddr).Port)
+	if err != nil {
+		t.Fatalf("Found errors calling probePorts %v", err)
+	}
+
+	if answer != expectedAnswer {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, answer)
+	}
+}
+
+func TestUsingDefaultCredentials(t *testing.T) {
+	mux = http.NewServeMux()
+	server = httptest.NewTLSServer(mux)
+	defer tearDown()
+	ip := strings.TrimPrefix(server.URL, "https://")
+
+	mux.HandleFunc("/cgi/login.cgi", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.FormValue("name") == "ADMIN" && r.FormValue("pwd") == "ADMIN" {
+			w.Write([]byte("../cgi/url_redirect.cgi?url_name=mainmenu"))
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+
+	mux.HandleFunc("/cgi/ipmi.cgi", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<?xml version="1.0"?>
+			<IPMI>
+			  <FRU_INFO RES="1">
+				<DEVICE ID="0"/>
+				<CHASSIS TYPE="1" PART_NUM="CSE-F414IS2-R2K04BP" SERIAL_NUM="CF414AF38N50003"/>
+				<BOARD LAN="0" MFG_DATE="1996/01/01 00:00:00" PROD_NAME="X10DRFF-CTG" MFC_NAME="Supermicro" SERIAL_NUM="VM158S009467" PART_NUM="X10DRFF-CTG"/>
+				<PRODUCT LAN="0" MFC_NAME="Supermicro" PROD_NAME="NONE" PART_NUM="SYS-F618H6-FTPTL+" VERSION="NONE" SERIAL_NUM="A19627226A05569" ASSET_TAG="NONE"/>
+			  </FRU_INFO>
+			</IPMI>`))
+	})
+
+	match, vendor, err := UsingDefaultCredentials(ip)
+	if err != nil {
+		t.Fatalf("Found errors calling UsingDefaultCredentials %v", err)
+	}
+
+	if !match || vendor != devices.Supermicro {
+		t.Errorf("Expected answer %v %v: found %v %v", true, devices.Supermicro, match, vendor)
+	}
+
+	defaults := DefaultCredentials[devices.Supermicro]
+	defer func() { DefaultCredentials[devices.Supermicro] = defaults }()
+	DefaultCredentials[devices.Supermicro] = []Credential{{Username: "ADMIN", Password: "changed"}}
+
+	match, _, err = UsingDefaultCredentials(ip)
+	if err != nil {
+		t.Fatalf("Found errors calling UsingDefaultCredentials %v", err)
+	}
+
+	if match {
+		t.Errorf("Expected answer %v: found %v", false, match)
+	}
+}
+
+func TestScanAndConnectUnreachable(t *testing.T) {
+	expectedAnswer := errors.ErrUnreachable
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+	closed.Close()
+
+	_, err = ScanAndConnect(closed.Addr().String(), "super", "test")
+	connectErr, ok := err.(*errors.ConnectError)
+	if !ok {
+		t.Fatalf("Expected a ConnectError calling ScanAndConnect: found %v", err)
+	}
+
+	if connectErr.Reason != expectedAnswer {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, connectErr.Reason)
+	}
+
+	if !goerrors.Is(err, expectedAnswer) {
+		t.Errorf("Expected the error to wrap %v: found %v", expectedAnswer, err)
+	}
+}
+
+func TestConnectAuthentication(t *testing.T) {
+	expectedAnswer := errors.ErrAuthentication
+
+	_, err := setup(answers["SupermicroX10"])
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+	defer tearDown()
+
+	_, err = Connect(strings.TrimPrefix(server.URL, "https://"), "super", "test")
+	connectErr, ok := err.(*errors.ConnectError)
+	if !ok {
+		t.Fatalf("Expected a ConnectError calling Connect: found %v", err)
+	}
+
+	if connectErr.Reason != expectedAnswer {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, connectErr.Reason)
+	}
+
+	if !goerrors.Is(err, expectedAnswer) {
+		t.Errorf("Expected the error to wrap %v: found %v", expectedAnswer, err)
+	}
+}
@@ -0,0 +1,196 @@
+package discover
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bmc-toolbox/bmclib/devices"
+	"github.com/bmc-toolbox/bmclib/errors"
+	"github.com/bmc-toolbox/bmclib/internal/sshclient"
+)
+
+// probeTimeout is used for each port when the context has no deadline
+const probeTimeout = 3 * time.Second
+
+// rmcpPing is the ASF presence ping sent over rmcp, bmcs answer it with a presence pong
+// without requiring any authentication
+var rmcpPing = []byte{0x06, 0x00, 0xff, 0x06, 0x00, 0x00, 0x11, 0xbe, 0x80, 0x00, 0x00, 0x00}
+
+// sshBanners maps the software names found in the identification string of the embedded ssh
+// servers to their vendor, the bmcs running a stock OpenSSH can't be told apart by it
+var sshBanners = map[string]string{
+	"mpSSH": devices.HP,
+	"iDRAC": devices.Dell,
+}
+
+// sshPrompts maps the prompts printed by the cli of the bmcs once logged in over ssh to the
+// vendor and model returned by DetectVendor
+var sshPrompts = map[string]string{
+	"hpiLO->":   "hp-ilo",
+	"/admin1->": "dell-idrac8",
+}
+
+// PortStatus tells which management services answered on a host
+type PortStatus struct {
+	SSH     bool
+	Redfish bool
+	IPMI    bool
+}
+
+// ProbePorts checks in parallel which of the ssh, https/redfish and ipmi ports of the host are reachable
+// without authenticating, the context bounds the time taken by the whole probe and the ports
+// that didn't answer before it expired are reported as closed
+func ProbePorts(ctx context.Context, host string) (status PortStatus, err error) {
+	return probePorts(ctx, host, devices.SSHPort, devices.RedfishPort, devices.IPMIPort)
+}
+
+func probePorts(ctx context.Context, host string, sshPort int, redfishPort int, ipmiPort int) (status PortStatus, err error) {
+	if host == "" {
+		return status, fmt.Errorf("no host given to probe")
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+
+	go func() {
+		defer wg.Done()
+		status.SSH = probeTCP(ctx, net.JoinHostPort(host, strconv.Itoa(sshPort)))
+	}()
+
+	go func() {
+		defer wg.Done()
+		status.Redfish = probeTCP(ctx, net.JoinHostPort(host, strconv.Itoa(redfishPort)))
+	}()
+
+	go func() {
+		defer wg.Done()
+		status.IPMI = probeRMCP(ctx, net.JoinHostPort(host, strconv.Itoa(ipmiPort)))
+	}()
+
+	wg.Wait()
+
+	return status, err
+}
+
+// probeTCP tells if the given address accepts tcp connections
+func probeTCP(ctx context.Context, address string) bool {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+
+	return true
+}
+
+// probeRMCP tells if the given address answers the rmcp presence ping, udp being
+// connectionless the only way to know something is listening is getting an answer
+func probeRMCP(ctx context.Context, address string) bool {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", address)
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	_, err = conn.Write(rmcpPing)
+	if err != nil {
+		return false
+	}
+
+	pong := make([]byte, 64)
+	n, err := conn.Read(pong)
+	if err != nil || n < 9 {
+		return false
+	}
+
+	// rmcp header with the asf class and the presence pong message type (0x40)
+	return bytes.Equal(pong[:4], rmcpPing[:4]) && pong[8] == 0x40
+}
+
+// SSHVendor reads the identification string the ssh server at the given address sends right after
+// connecting, no authentication involved, and returns the vendor it gives away along with it.
+// errors.ErrVendorUnknown is returned when the banner doesn't tell the vendor
+func SSHVendor(ctx context.Context, address string) (vendor string, banner string, err error) {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return vendor, banner, err
+	}
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	// SSH-2.0-mpSSH_0.2.1, servers may send other lines before it
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return vendor, banner, err
+		}
+
+		if strings.HasPrefix(line, "SSH-") {
+			banner = strings.TrimSpace(line)
+			break
+		}
+	}
+
+	for software, vendor := range sshBanners {
+		if strings.Contains(banner, software) {
+			return vendor, banner, err
+		}
+	}
+
+	return vendor, banner, errors.ErrVendorUnknown
+}
+
+// DetectVendor logs in over ssh and tells the bmc from the prompt its cli prints, e.g. "dell-idrac8"
+// or "hp-ilo", see sshPrompts. "unknown" is returned without any error when the prompt isn't recognized
+func DetectVendor(host string, username string, password string) (vendor string, err error) {
+	vendor = "unknown"
+
+	client, err := sshclient.New(host, username, password)
+	if err != nil {
+		return vendor, err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+
+	// both clis print prompts ending with ->, the unknown bmcs are read until the timeout
+	output, err := client.Prompt(ctx, "->")
+	for prompt, model := range sshPrompts {
+		if strings.Contains(output, prompt) {
+			return model, nil
+		}
+	}
+
+	if err != nil && err != io.EOF && ctx.Err() == nil {
+		return vendor, err
+	}
+
+	return vendor, nil
+}
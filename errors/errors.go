@@ -1,6 +1,12 @@
 package errors
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
 
 var (
 	// ErrLoginFailed is returned when we fail to login to a bmc
@@ -25,6 +31,30 @@ var (
 	Err500 = errors.New("we've received 500 calling this endpoint")
 	// ErrNotImplemented is returned for not implemented methods called
 	ErrNotImplemented = errors.New("this feature hasn't been implemented yet")
+	// ErrNotLoggedIn is returned when an action requires a session that wasn't established
+	ErrNotLoggedIn = errors.New("no session established with the bmc, call Login() first")
+	// ErrJobNotCancellable is returned when a job went past the point it can be cancelled, e.g. a firmware being flashed
+	ErrJobNotCancellable = errors.New("the job is already running and can't be cancelled")
+	// ErrJobNotFound is returned when the job an action refers to isn't in the job queue of the bmc
+	ErrJobNotFound = errors.New("the job doesn't exist in the job queue")
+	// ErrMaxAttemptsReached is returned when polling the bmc gave up before the condition was met
+	ErrMaxAttemptsReached = errors.New("the condition wasn't met within the maximum number of attempts")
+	// ErrUnreachable is returned when none of the management ports of the host answered
+	ErrUnreachable = errors.New("the bmc is unreachable")
+	// ErrNoSupportedTransport is returned when the host answers but not over a transport we can detect the bmc with
+	ErrNoSupportedTransport = errors.New("the bmc doesn't answer over any supported transport")
+	// ErrAuthentication is returned when the bmc was detected but rejected the credentials
+	ErrAuthentication = errors.New("the bmc rejected the credentials")
+	// ErrConnectionTimeout is returned when the connection to the bmc couldn't be established within the timeout
+	ErrConnectionTimeout = errors.New("timed out connecting to the bmc")
+	// ErrUserNotFound is returned when the user account an action refers to doesn't exist in the bmc
+	ErrUserNotFound = errors.New("the user doesn't exist in the bmc")
+	// ErrNotConfirmed is returned by the destructive actions called without the explicit confirmation
+	ErrNotConfirmed = errors.New("the action is destructive and wasn't confirmed")
+	// ErrNoData is returned when the bmc has nothing stored for the requested data, e.g. no crash screen was captured
+	ErrNoData = errors.New("the bmc has no data stored for the request")
+	// ErrOutputTruncated is wrapped by OutputTooLargeError, it allows checking for it with errors.Is
+	ErrOutputTruncated = errors.New("the output of the command was truncated")
 	// ErrFeatureUnavailable is returned for features not available/supported.
 	ErrFeatureUnavailable = errors.New("this feature isn't supported/available for this hardware.")
 
@@ -34,3 +64,113 @@ var (
 	// Err401Redfish indicates auth failure
 	Err401Redfish = errors.New("Redfish authorization failed.")
 )
+
+// BMCReadOnlyError is returned by the actions changing the device when the bmc only
+// accepts reads, as it happens when it's in maintenance or recovery mode
+type BMCReadOnlyError struct {
+	Host string
+}
+
+func (e *BMCReadOnlyError) Error() string {
+	return fmt.Sprintf("bmc %s is in read-only mode, writes are being rejected", e.Host)
+}
+
+// SOLBusyError is returned when the serial over lan console is already active in another
+// session, opening it again with force disconnects the other session
+type SOLBusyError struct {
+	Host string
+}
+
+func (e *SOLBusyError) Error() string {
+	return fmt.Sprintf("sol console of %s is already in use by another session", e.Host)
+}
+
+// OutputTooLargeError is returned when a command printed more than the maximum output size,
+// the command is aborted and Output holds what was read up to the limit
+type OutputTooLargeError struct {
+	Command string
+	Limit   int
+	Output  string
+}
+
+func (e *OutputTooLargeError) Error() string {
+	return fmt.Sprintf("output of %q exceeded the limit of %d bytes, the command was aborted", e.Command, e.Limit)
+}
+
+// Unwrap returns ErrOutputTruncated
+func (e *OutputTooLargeError) Unwrap() error {
+	return ErrOutputTruncated
+}
+
+// ConnectError is returned when no working connection to the bmc could be made, Reason is one of
+// ErrUnreachable, ErrNoSupportedTransport or ErrAuthentication and Attempts holds the result of each
+// transport tried, nil for the transports that answered. Vendor is set when the ssh banner gave it away
+type ConnectError struct {
+	Host     string
+	Reason   error
+	Attempts map[string]error
+	Vendor   string
+}
+
+func (e *ConnectError) Error() string {
+	transports := make([]string, 0, len(e.Attempts))
+	for transport := range e.Attempts {
+		transports = append(transports, transport)
+	}
+	sort.Strings(transports)
+
+	attempts := make([]string, 0, len(transports))
+	for _, transport := range transports {
+		result := "ok"
+		if e.Attempts[transport] != nil {
+			result = e.Attempts[transport].Error()
+		}
+		attempts = append(attempts, fmt.Sprintf("%s: %s", transport, result))
+	}
+
+	if e.Vendor != "" {
+		attempts = append(attempts, fmt.Sprintf("vendor: %s", e.Vendor))
+	}
+
+	return fmt.Sprintf("unable to connect to %s: %v (%s)", e.Host, e.Reason, strings.Join(attempts, ", "))
+}
+
+// Unwrap returns the Reason, it allows checking for it with errors.Is
+func (e *ConnectError) Unwrap() error {
+	return e.Reason
+}
+
+// ClockSkewError is returned when the bmc rejected the login while its clock is off by Skew
+// from the local one, the certificates and tickets it checks look expired or not yet valid.
+// Err is the login failure
+type ClockSkewError struct {
+	Host string
+	Skew time.Duration
+	Err  error
+}
+
+func (e *ClockSkewError) Error() string {
+	return fmt.Sprintf("login to %s failed and its clock is off by %s, it's likely the cause: %v", e.Host, e.Skew, e.Err)
+}
+
+// Unwrap returns the login failure
+func (e *ClockSkewError) Unwrap() error {
+	return e.Err
+}
+
+// CommandError is returned when a command run on the bmc failed or didn't print the expected
+// answer, the message is the output of the command as printed by the bmc
+type CommandError struct {
+	Cmd    string
+	Output string
+	Err    error
+}
+
+func (e *CommandError) Error() string {
+	return e.Output
+}
+
+// Unwrap returns the error the command failed with, nil when it just printed an unexpected answer
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
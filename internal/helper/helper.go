@@ -1,8 +1,11 @@
 package helper
 
 import (
+	"reflect"
 	"regexp"
 	"runtime"
+
+	"github.com/bmc-toolbox/bmclib/devices"
 )
 
 var basename = regexp.MustCompile("^.+\\.(.*$)")
@@ -15,3 +18,15 @@ func WhosCalling() string {
 	}
 	return "unknown"
 }
+
+// SupportedActions returns the devices.Actions implemented by the given provider
+func SupportedActions(provider interface{}) (actions []string) {
+	providerType := reflect.TypeOf(provider)
+	for _, action := range devices.Actions {
+		if _, ok := providerType.MethodByName(action); ok {
+			actions = append(actions, action)
+		}
+	}
+
+	return actions
+}
@@ -1,6 +1,9 @@
 package helper
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 func TestWhosCalling(t *testing.T) {
 	expectedAnswer := "TestWhosCalling"
@@ -11,3 +14,19 @@ func TestWhosCalling(t *testing.T) {
 		t.Errorf("Expected answer %v: found %v", expectedAnswer, answer)
 	}
 }
+
+type actionsProvider struct{}
+
+func (p *actionsProvider) PowerOn() (bool, error)  { return true, nil }
+func (p *actionsProvider) PowerOff() (bool, error) { return true, nil }
+func (p *actionsProvider) Serial() (string, error) { return "", nil }
+
+func TestSupportedActions(t *testing.T) {
+	expectedAnswer := []string{"PowerOff", "PowerOn"}
+
+	answer := SupportedActions(&actionsProvider{})
+
+	if !reflect.DeepEqual(answer, expectedAnswer) {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, answer)
+	}
+}
@@ -1,10 +1,17 @@
 package ipmi
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"os"
 	"os/exec"
 	"strings"
+	"time"
+
+	"github.com/bmc-toolbox/bmclib/devices"
+	"github.com/bmc-toolbox/bmclib/errors"
+	"github.com/bmc-toolbox/bmclib/internal/redact"
 )
 
 // Ipmi holds the date for an ipmi connection
@@ -15,7 +22,7 @@ type Ipmi struct {
 	ipmitool string
 }
 
-// New returns a new ipmi instance
+// New returns a new ipmi instance, the host may carry a port when not using devices.IPMIPort
 func New(username string, password string, host string) (ipmi *Ipmi, err error) {
 	ipmi = &Ipmi{
 		Username: username,
@@ -32,12 +39,21 @@ func New(username string, password string, host string) (ipmi *Ipmi, err error)
 }
 
 func (i *Ipmi) run(command []string) (output string, err error) {
+	out, err := i.command(context.Background(), command).CombinedOutput()
+	return redact.String(string(out)), err
+}
+
+// command returns the ipmitool command running the given ipmi command, the password is
+// passed through the environment so it doesn't show in the process list
+func (i *Ipmi) command(ctx context.Context, command []string) (cmd *exec.Cmd) {
 	ipmiArgs := []string{"-I", "lanplus", "-U", i.Username, "-E", "-H", i.Host}
+	if host, port, err := net.SplitHostPort(i.Host); err == nil {
+		ipmiArgs = []string{"-I", "lanplus", "-U", i.Username, "-E", "-H", host, "-p", port}
+	}
 	ipmiArgs = append(ipmiArgs, command...)
-	cmd := exec.Command(i.ipmitool, ipmiArgs...)
+	cmd = exec.CommandContext(ctx, i.ipmitool, ipmiArgs...)
 	cmd.Env = []string{fmt.Sprintf("IPMITOOL_PASSWORD=%s", i.Password)}
-	out, err := cmd.CombinedOutput()
-	return string(out), err
+	return cmd
 }
 
 func (i *Ipmi) findBin(binary string) (binaryPath string, err error) {
@@ -56,6 +72,11 @@ func (i *Ipmi) findBin(binary string) (binaryPath string, err error) {
 		}
 	}
 
+	// installed elsewhere, e.g. by a package manager using its own prefix
+	if lookup, err := exec.LookPath(binary); err == nil {
+		return lookup, nil
+	}
+
 	return binaryPath, fmt.Errorf("Unable to find binary: %v", binary)
 }
 
@@ -72,6 +93,19 @@ func (i *Ipmi) PowerCycle() (status bool, err error) {
 	return false, fmt.Errorf("%v: %v", err, output)
 }
 
+// PowerCycleCold removes the power from the machine and powers it on again via bmc
+func (i *Ipmi) PowerCycleCold() (status bool, err error) {
+	output, err := i.run([]string{"chassis", "power", "cycle"})
+	if err != nil {
+		return false, fmt.Errorf("%v: %v", err, output)
+	}
+
+	if strings.HasPrefix(output, "Chassis Power Control: Cycle") {
+		return true, err
+	}
+	return false, fmt.Errorf("%v: %v", err, output)
+}
+
 // PowerCycleBmc reboots the bmc we are connected to
 func (i *Ipmi) PowerCycleBmc() (status bool, err error) {
 	output, err := i.run([]string{"mc", "reset", "cold"})
@@ -138,6 +172,27 @@ func (i *Ipmi) PowerOff() (status bool, err error) {
 	return false, fmt.Errorf("%v: %v", err, output)
 }
 
+// PressPowerButton emulates a press of the power button, a held press forces the machine
+// off while a momentary press sends the acpi soft shutdown signal to the operating system
+func (i *Ipmi) PressPowerButton(hold bool) (status bool, err error) {
+	command := []string{"chassis", "power", "soft"}
+	expected := "Chassis Power Control: Soft"
+	if hold {
+		command = []string{"chassis", "power", "off"}
+		expected = "Chassis Power Control: Down/Off"
+	}
+
+	output, err := i.run(command)
+	if err != nil {
+		return false, fmt.Errorf("%v: %v", err, output)
+	}
+
+	if strings.Contains(output, expected) {
+		return true, err
+	}
+	return false, fmt.Errorf("%v: %v", err, output)
+}
+
 // PxeOnceEfi makes the machine to boot via pxe once using EFI
 func (i *Ipmi) PxeOnceEfi() (status bool, err error) {
 	output, err := i.run([]string{"chassis", "bootdev", "pxe", "options=efiboot"})
@@ -169,6 +224,55 @@ func (i *Ipmi) PxeOnce() (status bool, err error) {
 	return i.PxeOnceMbr()
 }
 
+// ipmiRestorePolicies maps the power restore policies to the ones used by ipmitool
+var ipmiRestorePolicies = map[devices.PowerRestorePolicy]string{
+	devices.PowerRestoreAlwaysOn:  "always-on",
+	devices.PowerRestoreAlwaysOff: "always-off",
+	devices.PowerRestoreLast:      "previous",
+}
+
+// GetPowerRestorePolicy returns the power state the machine goes to when the AC power comes back
+func (i *Ipmi) GetPowerRestorePolicy() (policy devices.PowerRestorePolicy, err error) {
+	output, err := i.run([]string{"chassis", "status"})
+	if err != nil {
+		return policy, fmt.Errorf("%v: %v", err, output)
+	}
+
+	// Power Restore Policy : always-off
+	for _, line := range strings.Split(output, "\n") {
+		data := strings.SplitN(line, ":", 2)
+		if len(data) != 2 || strings.TrimSpace(data[0]) != "Power Restore Policy" {
+			continue
+		}
+
+		for p, value := range ipmiRestorePolicies {
+			if strings.TrimSpace(data[1]) == value {
+				return p, err
+			}
+		}
+	}
+
+	return policy, fmt.Errorf("unable to find the power restore policy: %v", output)
+}
+
+// SetPowerRestorePolicy defines the power state the machine goes to when the AC power comes back
+func (i *Ipmi) SetPowerRestorePolicy(policy devices.PowerRestorePolicy) (status bool, err error) {
+	value, ok := ipmiRestorePolicies[policy]
+	if !ok {
+		return false, fmt.Errorf("unknown power restore policy: %v", policy)
+	}
+
+	output, err := i.run([]string{"chassis", "policy", value})
+	if err != nil {
+		return false, fmt.Errorf("%v: %v", err, output)
+	}
+
+	if strings.Contains(output, "Set chassis power restore policy to") {
+		return true, err
+	}
+	return false, fmt.Errorf("%v: %v", err, output)
+}
+
 // IsOn tells if a machine is currently powered on
 func (i *Ipmi) IsOn() (status bool, err error) {
 	output, err := i.run([]string{"chassis", "power", "status"})
@@ -179,5 +283,74 @@ func (i *Ipmi) IsOn() (status bool, err error) {
 	if strings.Contains(output, "Chassis Power is on") {
 		return true, err
 	}
-	return false, err
+	if strings.Contains(output, "Chassis Power is off") {
+		return false, err
+	}
+	return false, fmt.Errorf("unknown power status: %v", output)
+}
+
+// RecoveryCounters counts the watchdog resets (ASR) and NMIs recorded in the SEL
+func (i *Ipmi) RecoveryCounters() (stats devices.RecoveryStats, err error) {
+	output, err := i.run([]string{"sel", "elist"})
+	if err != nil {
+		return stats, fmt.Errorf("%v: %v", err, output)
+	}
+
+	// 1 | 11/15/2017 | 18:49:59 | Watchdog2 #0x01 | Hard reset | Asserted
+	for _, line := range strings.Split(output, "\n") {
+		data := strings.Split(line, "|")
+		if len(data) < 6 || strings.TrimSpace(data[5]) != "Asserted" {
+			continue
+		}
+
+		sensor := strings.ToLower(data[3])
+		event := strings.ToLower(data[4])
+
+		if strings.Contains(sensor, "watchdog") && !strings.Contains(event, "timer expired") {
+			stats.ASRCount++
+			stamp := fmt.Sprintf("%s %s", strings.TrimSpace(data[1]), strings.TrimSpace(data[2]))
+			if t, err := time.Parse("01/02/2006 15:04:05", stamp); err == nil && t.After(stats.LastASR) {
+				stats.LastASR = t
+			}
+		} else if strings.Contains(sensor, "critical interrupt") || strings.Contains(event, "nmi") {
+			stats.NMICount++
+		}
+	}
+
+	return stats, err
+}
+
+// ChassisIntrusion reads the physical security sensor, errors.ErrFeatureUnavailable is returned
+// when the machine has no intrusion sensor fitted
+func (i *Ipmi) ChassisIntrusion() (status devices.IntrusionStatus, err error) {
+	output, err := i.run([]string{"sdr", "type", "Physical Security"})
+	if err != nil {
+		return devices.IntrusionUnknown, fmt.Errorf("%v: %v", err, output)
+	}
+
+	return parseIntrusion(output)
+}
+
+// parseIntrusion reads the intrusion sensor from the output of sdr type, the event is only
+// printed while the intrusion is asserted
+//
+// Intrusion        | 73h | ok  | 23.1 | General Chassis intrusion
+func parseIntrusion(output string) (status devices.IntrusionStatus, err error) {
+	for _, line := range strings.Split(output, "\n") {
+		data := strings.Split(line, "|")
+		if len(data) < 5 || !strings.Contains(strings.ToLower(data[0]), "intrusion") {
+			continue
+		}
+
+		switch {
+		case strings.TrimSpace(data[2]) == "ns":
+			return devices.IntrusionUnknown, nil
+		case strings.Contains(strings.ToLower(data[4]), "intrusion"):
+			return devices.IntrusionOpen, nil
+		default:
+			return devices.IntrusionClosed, nil
+		}
+	}
+
+	return devices.IntrusionUnknown, errors.ErrFeatureUnavailable
 }
@@ -0,0 +1,116 @@
+package ipmi
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bmc-toolbox/bmclib/devices"
+	"github.com/bmc-toolbox/bmclib/errors"
+)
+
+func TestParseIntrusion(t *testing.T) {
+	tt := map[string]devices.IntrusionStatus{
+		"Intrusion        | 73h | ok  | 23.1 | \n":                          devices.IntrusionClosed,
+		"Intrusion        | 73h | ok  | 23.1 | General Chassis intrusion\n": devices.IntrusionOpen,
+		"Intrusion        | 73h | ns  | 23.1 | No Reading\n":                devices.IntrusionUnknown,
+	}
+
+	for output, expectedAnswer := range tt {
+		answer, err := parseIntrusion(output)
+		if err != nil {
+			t.Fatalf("Found errors calling parseIntrusion %v", err)
+		}
+
+		if answer != expectedAnswer {
+			t.Errorf("Expected answer %v: found %v", expectedAnswer, answer)
+		}
+	}
+
+	_, err := parseIntrusion("")
+	if err != errors.ErrFeatureUnavailable {
+		t.Errorf("Expected answer %v: found %v", errors.ErrFeatureUnavailable, err)
+	}
+}
+
+// setupIpmitool returns an Ipmi running a fake ipmitool, it records its arguments and environment
+// and prints the given answer
+func setupIpmitool(t *testing.T, answer string) (i *Ipmi, record string, tearDown func()) {
+	dir, err := ioutil.TempDir("", "ipmitool")
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+
+	record = filepath.Join(dir, "record")
+	script := fmt.Sprintf(`#!/bin/sh
+echo "$IPMITOOL_PASSWORD $@" > %s
+printf '%%s\n' "%s"
+`, record, answer)
+
+	ipmitool := filepath.Join(dir, "ipmitool")
+	err = ioutil.WriteFile(ipmitool, []byte(script), 0755)
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+
+	i = &Ipmi{Username: "super", Password: "test", Host: "127.0.0.1:6230", ipmitool: ipmitool}
+	return i, record, func() { os.RemoveAll(dir) }
+}
+
+func TestIsOn(t *testing.T) {
+	tt := []struct {
+		answer string
+		status bool
+		fails  bool
+	}{
+		{"Chassis Power is on", true, false},
+		{"Chassis Power is off", false, false},
+		{"Error: Unable to establish IPMI v2 / RMCP+ session", false, true},
+	}
+
+	for _, tc := range tt {
+		i, record, tearDown := setupIpmitool(t, tc.answer)
+
+		status, err := i.IsOn()
+		if status != tc.status || (err != nil) != tc.fails {
+			t.Errorf("Expected answer %v %v: found %v %v", tc.status, tc.fails, status, err)
+		}
+
+		// the password goes through the environment, the port is split from the host
+		expectedAnswer := "test -I lanplus -U super -E -H 127.0.0.1 -p 6230 chassis power status"
+		args, err := ioutil.ReadFile(record)
+		if err != nil {
+			t.Fatalf("Found errors reading the ipmitool arguments %v", err)
+		}
+
+		if answer := strings.TrimSpace(string(args)); answer != expectedAnswer {
+			t.Errorf("Expected answer %v: found %v", expectedAnswer, answer)
+		}
+
+		tearDown()
+	}
+}
+
+func TestPowerCycle(t *testing.T) {
+	expectedAnswer := "test -I lanplus -U super -E -H 127.0.0.1 -p 6230 chassis power reset"
+
+	i, record, tearDown := setupIpmitool(t, "Chassis Power Control: Reset")
+	defer tearDown()
+
+	status, err := i.PowerCycle()
+	if err != nil || !status {
+		t.Fatalf("Found errors calling PowerCycle %v", err)
+	}
+
+	args, err := ioutil.ReadFile(record)
+	if err != nil {
+		t.Fatalf("Found errors reading the ipmitool arguments %v", err)
+	}
+
+	if answer := strings.TrimSpace(string(args)); answer != expectedAnswer {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, answer)
+	}
+}
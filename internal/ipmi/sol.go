@@ -0,0 +1,102 @@
+package ipmi
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/bmc-toolbox/bmclib/errors"
+)
+
+// SOL opens the serial over lan console of the machine, the console is closed when ctx is done.
+// Everything printed by the console is also written to record when given. When the console is
+// already active in another session a *errors.SOLBusyError is returned unless force is set, in
+// which case the other session is disconnected first
+func (i *Ipmi) SOL(ctx context.Context, record io.Writer, force bool) (console io.ReadWriteCloser, err error) {
+	if force {
+		output, err := i.run([]string{"sol", "deactivate"})
+		if err != nil && !strings.Contains(output, "not active") {
+			return console, fmt.Errorf("%v: %v", err, output)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cmd := i.command(ctx, []string{"sol", "activate"})
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		cancel()
+		return console, err
+	}
+
+	reader, writer := io.Pipe()
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+
+	err = cmd.Start()
+	if err != nil {
+		cancel()
+		return console, err
+	}
+
+	session := &solSession{stdin: stdin, output: reader, cancel: cancel, done: make(chan struct{})}
+	go func() {
+		cmd.Wait()
+		writer.Close()
+		close(session.done)
+	}()
+
+	// ipmitool tells right away whether the session could be established
+	output := bufio.NewReader(reader)
+	banner, err := output.ReadString('\n')
+	switch {
+	case strings.Contains(banner, "SOL Session operational"):
+	case strings.Contains(banner, "already active"):
+		session.Close()
+		return console, &errors.SOLBusyError{Host: i.Host}
+	default:
+		session.Close()
+		if ctx.Err() != nil {
+			return console, ctx.Err()
+		}
+		return console, fmt.Errorf("unable to activate sol: %s", strings.TrimSpace(banner))
+	}
+
+	session.Reader = output
+	if record != nil {
+		session.Reader = io.TeeReader(output, record)
+	}
+
+	return session, nil
+}
+
+// solSession is the console returned by SOL, backed by ipmitool sol activate
+type solSession struct {
+	io.Reader
+	stdin  io.WriteCloser
+	output *io.PipeReader
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+// Write sends the given bytes to the console
+func (s *solSession) Write(p []byte) (n int, err error) {
+	return s.stdin.Write(p)
+}
+
+// Close terminates the ipmitool session and waits for it to exit
+func (s *solSession) Close() (err error) {
+	s.once.Do(func() {
+		s.stdin.Close()
+		s.cancel()
+		// unblock ipmitool if it's still printing output nobody reads
+		s.output.Close()
+		<-s.done
+	})
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package ipmi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bmc-toolbox/bmclib/errors"
+)
+
+// setupSOL returns an Ipmi running a fake ipmitool, the console echoes what it's sent back
+// and reports another active session as long as the busy file exists
+func setupSOL(t *testing.T) (i *Ipmi, busy string, tearDown func()) {
+	dir, err := ioutil.TempDir("", "sol")
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+
+	busy = filepath.Join(dir, "busy")
+	script := fmt.Sprintf(`#!/bin/sh
+for arg in "$@"; do
+	case "$arg" in
+	deactivate)
+		rm -f %[1]s
+		echo "Deactivating SOL payload"
+		exit 0;;
+	activate)
+		if [ -f %[1]s ]; then
+			echo "Info: SOL payload already active on another session"
+			exit 1
+		fi
+		echo "[SOL Session operational.  Use ~? for help]"
+		echo "boot"
+		exec cat;;
+	esac
+done
+exit 1
+`, busy)
+
+	ipmitool := filepath.Join(dir, "ipmitool")
+	err = ioutil.WriteFile(ipmitool, []byte(script), 0755)
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+
+	i = &Ipmi{Username: "super", Password: "test", Host: "127.0.0.1", ipmitool: ipmitool}
+	return i, busy, func() { os.RemoveAll(dir) }
+}
+
+func TestSOL(t *testing.T) {
+	expectedAnswer := "boot\nhello\n"
+
+	i, _, tearDown := setupSOL(t)
+	defer tearDown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	record := &bytes.Buffer{}
+
+	console, err := i.SOL(ctx, record, false)
+	if err != nil {
+		t.Fatalf("Found errors calling i.SOL %v", err)
+	}
+	defer console.Close()
+
+	output := bufio.NewReader(console)
+	line, err := output.ReadString('\n')
+	if err != nil || line != "boot\n" {
+		t.Fatalf("Expected answer %q: found %q %v", "boot\n", line, err)
+	}
+
+	_, err = console.Write([]byte("hello\n"))
+	if err != nil {
+		t.Fatalf("Found errors writing to the console %v", err)
+	}
+
+	line, err = output.ReadString('\n')
+	if err != nil || line != "hello\n" {
+		t.Fatalf("Expected answer %q: found %q %v", "hello\n", line, err)
+	}
+
+	// cancelling the context ends the session
+	cancel()
+	done := make(chan error)
+	go func() {
+		_, err := ioutil.ReadAll(output)
+		done <- err
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the console to be closed after the context was cancelled")
+	}
+
+	if record.String() != expectedAnswer {
+		t.Errorf("Expected answer %q: found %q", expectedAnswer, record.String())
+	}
+}
+
+func TestSOLBusy(t *testing.T) {
+	i, busy, tearDown := setupSOL(t)
+	defer tearDown()
+
+	err := ioutil.WriteFile(busy, []byte{}, 0644)
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+
+	_, err = i.SOL(context.Background(), nil, false)
+	if _, ok := err.(*errors.SOLBusyError); !ok {
+		t.Fatalf("Expected answer %T: found %v", &errors.SOLBusyError{}, err)
+	}
+
+	console, err := i.SOL(context.Background(), nil, true)
+	if err != nil {
+		t.Fatalf("Found errors calling i.SOL with force %v", err)
+	}
+
+	err = console.Close()
+	if err != nil {
+		t.Errorf("Found errors closing the console %v", err)
+	}
+}
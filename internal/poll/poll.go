@@ -0,0 +1,51 @@
+package poll
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/bmc-toolbox/bmclib/devices"
+	"github.com/bmc-toolbox/bmclib/errors"
+)
+
+const (
+	// DefaultInterval is used when the options don't define one
+	DefaultInterval = 5 * time.Second
+	// maxBackoff bounds how much the delay between attempts grows, relative to the interval
+	maxBackoff = 4
+)
+
+// Until calls condition until it's done, it returns an error, ctx is done or the max attempts
+// are reached. The delay between attempts starts at the interval and backs off with some jitter
+// so multiple callers don't hammer the bmc at the same time
+func Until(ctx context.Context, options devices.PollOptions, condition func() (done bool, err error)) (err error) {
+	interval := options.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	delay := interval
+	for attempt := 1; ; attempt++ {
+		done, err := condition()
+		if err != nil || done {
+			return err
+		}
+
+		if options.MaxAttempts > 0 && attempt >= options.MaxAttempts {
+			return errors.ErrMaxAttemptsReached
+		}
+
+		timer := time.NewTimer(delay + time.Duration(rand.Int63n(int64(delay)/5+1)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		if delay < maxBackoff*interval {
+			delay = delay * 3 / 2
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package poll
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bmc-toolbox/bmclib/devices"
+	"github.com/bmc-toolbox/bmclib/errors"
+)
+
+func TestUntil(t *testing.T) {
+	expectedAnswer := 3
+
+	attempts := 0
+	err := Until(context.Background(), devices.PollOptions{Interval: time.Millisecond}, func() (bool, error) {
+		attempts++
+		return attempts == 3, nil
+	})
+	if err != nil {
+		t.Fatalf("Found errors calling Until %v", err)
+	}
+
+	if attempts != expectedAnswer {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, attempts)
+	}
+}
+
+func TestUntilError(t *testing.T) {
+	expectedAnswer := fmt.Errorf("job failed")
+
+	err := Until(context.Background(), devices.PollOptions{Interval: time.Millisecond}, func() (bool, error) {
+		return false, expectedAnswer
+	})
+
+	if err != expectedAnswer {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, err)
+	}
+}
+
+func TestUntilMaxAttempts(t *testing.T) {
+	expectedAnswer := 2
+
+	attempts := 0
+	err := Until(context.Background(), devices.PollOptions{Interval: time.Millisecond, MaxAttempts: 2}, func() (bool, error) {
+		attempts++
+		return false, nil
+	})
+
+	if err != errors.ErrMaxAttemptsReached || attempts != expectedAnswer {
+		t.Errorf("Expected answer %v %v: found %v %v", errors.ErrMaxAttemptsReached, expectedAnswer, err, attempts)
+	}
+}
+
+func TestUntilContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := Until(ctx, devices.PollOptions{Interval: time.Hour}, func() (bool, error) {
+		return false, nil
+	})
+
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected answer %v: found %v", context.DeadlineExceeded, err)
+	}
+}
@@ -0,0 +1,47 @@
+package redact
+
+import (
+	"regexp"
+	"sync"
+)
+
+// Mask replaces the secrets found by String
+const Mask = "****"
+
+var (
+	mutex sync.RWMutex
+	// patterns match the secrets passed to the user/password commands, the first group
+	// is kept as is while the second one holds the secret
+	patterns = []*regexp.Regexp{
+		// racadm set iDRAC.Users.2.Password secret
+		regexp.MustCompile(`(?i)(\.Password\s+)("[^"]*"|\S+)`),
+		// racadm config -g cfgUserAdmin -o cfgUserAdminPassword -i 2 secret
+		regexp.MustCompile(`(?i)(cfgUserAdminPassword\s+(?:-i\s+\d+\s+)?)("[^"]*"|\S+)`),
+		// set /map1/accounts1/admin password=secret
+		regexp.MustCompile(`(?i)(password\s*=\s*)("[^"]*"|\S+)`),
+		// ipmitool user set password 2 secret
+		regexp.MustCompile(`(?i)(user set password\s+\d+\s+)("[^"]*"|\S+)`),
+		// ipmitool -P secret
+		regexp.MustCompile(`(\s-P\s+)("[^"]*"|\S+)`),
+		// racadm remoteimage -c -u user -p secret -l //host/share/image.iso
+		regexp.MustCompile(`(remoteimage\s.*\s-p\s+)("[^"]*"|\S+)`),
+	}
+)
+
+// Add registers an extra pattern, the secret is expected in its second group
+func Add(pattern *regexp.Regexp) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	patterns = append(patterns, pattern)
+}
+
+// String returns s with the known secrets replaced by Mask
+func String(s string) string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	for _, pattern := range patterns {
+		s = pattern.ReplaceAllString(s, "${1}"+Mask)
+	}
+
+	return s
+}
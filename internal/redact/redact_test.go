@@ -0,0 +1,36 @@
+package redact
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestString(t *testing.T) {
+	tt := map[string]string{
+		"racadm set iDRAC.Users.2.Password s3cr3t":                         "racadm set iDRAC.Users.2.Password ****",
+		"racadm config -g cfgUserAdmin -o cfgUserAdminPassword -i 2 x":     "racadm config -g cfgUserAdmin -o cfgUserAdminPassword -i 2 ****",
+		`set /map1/accounts1/admin password="s3 cr3t"`:                     "set /map1/accounts1/admin password=****",
+		"ipmitool user set password 2 s3cr3t":                              "ipmitool user set password 2 ****",
+		"ipmitool -U admin -P s3cr3t chassis status":                       "ipmitool -U admin -P **** chassis status",
+		"racadm remoteimage -c -u super -p s3cr3t -l //10.0.0.1/iso/a.iso": "racadm remoteimage -c -u super -p **** -l //10.0.0.1/iso/a.iso",
+		"racadm serveraction powerstatus":                                  "racadm serveraction powerstatus",
+	}
+
+	for input, expectedAnswer := range tt {
+		answer := String(input)
+		if answer != expectedAnswer {
+			t.Errorf("Expected answer %v: found %v", expectedAnswer, answer)
+		}
+	}
+}
+
+func TestAdd(t *testing.T) {
+	expectedAnswer := "token ****"
+
+	Add(regexp.MustCompile(`(token\s+)(\S+)`))
+
+	answer := String("token abcdef")
+	if answer != expectedAnswer {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, answer)
+	}
+}
@@ -1,12 +1,21 @@
 package sshclient
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"net"
 	"strings"
+	"sync"
 	"time"
 	"unicode"
+	"unicode/utf8"
 
+	"github.com/bmc-toolbox/bmclib/devices"
+	"github.com/bmc-toolbox/bmclib/errors"
+	"github.com/bmc-toolbox/bmclib/internal/redact"
+	log "github.com/sirupsen/logrus"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -27,11 +36,48 @@ const (
 	PowerCycleBmc = "powercyclebmc"
 	// PxeOnce  the action of pxe once a device
 	PxeOnce = "pxeonce"
+	// DefaultMaxOutputSize is the maximum number of bytes read from the output of a command
+	DefaultMaxOutputSize = 4 << 20
+	// logOutputSize is the number of bytes of the output passed to the logger
+	logOutputSize = 512
+	// DefaultTimeout is how long the dial and the handshake may take before giving up
+	DefaultTimeout = 15 * time.Second
 )
 
+// hostKeyWarning logs once per process that the host keys aren't verified
+var hostKeyWarning sync.Once
+
+// Options holds the optional settings used when connecting to a device
+type Options struct {
+	// Encoding defines how the command output is normalized, raw by default
+	Encoding string
+	// Ciphers, KeyExchanges and MACs override the algorithms offered during the handshake
+	Ciphers      []string
+	KeyExchanges []string
+	MACs         []string
+	// Port is used when the host doesn't carry one, devices.SSHPort by default
+	Port int
+	// TokenProvider when set supplies the token used to answer the keyboard-interactive prompt instead of the password
+	TokenProvider devices.TokenProvider
+	// Signer when set authenticates with its key first, the password or the token are tried if the key is rejected
+	Signer ssh.Signer
+	// HostKeyCallback verifies the host key of the bmc, any key is accepted by default, warning once per process
+	HostKeyCallback ssh.HostKeyCallback
+	// MaxOutputSize is the maximum number of bytes read from the output of a command, DefaultMaxOutputSize by default
+	MaxOutputSize int
+	// Timeout bounds the dial and the handshake together, DefaultTimeout by default
+	Timeout time.Duration
+	// Logger receives every command run and its output truncated, nothing is logged by default
+	Logger devices.Logger
+}
+
 // SSHClient implements out commom abstraction for ssh
 type SSHClient struct {
-	client *ssh.Client
+	client   *ssh.Client
+	host     string
+	options  Options
+	timings  devices.Timings
+	commands int
 }
 
 // Sleep transforms a sleep statement in a sleep-able time
@@ -48,18 +94,373 @@ func Sleep(sleep string) (err error) {
 
 // Run execute the given command and returns a string with the output
 func (s *SSHClient) Run(command string) (result string, err error) {
+	return s.run(context.Background(), command, nil, s.options.MaxOutputSize)
+}
+
+// RunContext execute the given command aborting it when ctx is done, in which case the
+// returned error wraps ctx.Err()
+func (s *SSHClient) RunContext(ctx context.Context, command string) (result string, err error) {
+	return s.run(ctx, command, nil, s.options.MaxOutputSize)
+}
+
+// RunWithLimit execute the given command reading at most limit bytes of its output, overriding
+// the MaxOutputSize of the client. A *errors.OutputTooLargeError is returned when the limit is exceeded
+func (s *SSHClient) RunWithLimit(command string, limit int) (result string, err error) {
+	return s.run(context.Background(), command, nil, limit)
+}
+
+// SessionError is returned when the ssh session of a command can't be opened, the command
+// never reached the host then and can be run again safely
+type SessionError struct {
+	Err error
+}
+
+// Error implements the error interface
+func (e *SessionError) Error() string {
+	return fmt.Sprintf("unable to open the ssh session: %v", e.Err)
+}
+
+// Unwrap returns the error of the ssh client
+func (e *SessionError) Unwrap() error {
+	return e.Err
+}
+
+// run executes the command feeding it the given stdin, the session is closed as soon as
+// the output grows past the limit so a runaway command can't exhaust the memory
+func (s *SSHClient) run(ctx context.Context, command string, stdin io.Reader, limit int) (result string, err error) {
+	if limit <= 0 {
+		limit = DefaultMaxOutputSize
+	}
+
 	session, err := s.client.NewSession()
 	if err != nil {
+		return result, &SessionError{Err: err}
+	}
+	defer session.Close()
+
+	output := &limitedBuffer{limit: limit, exceeded: make(chan struct{})}
+	session.Stdout = output
+	session.Stderr = output
+
+	// stdin is fed through a pipe as the bmc may close the channel before reading it,
+	// which would otherwise make Wait fail a command that exited successfully
+	var input io.WriteCloser
+	if stdin != nil {
+		input, err = session.StdinPipe()
+		if err != nil {
+			return result, err
+		}
+	}
+
+	logger := s.options.Logger
+	if logger == nil {
+		logger = devices.NopLogger{}
+	}
+	logger.Log("host", s.host, "command", redact.String(command))
+
+	start := time.Now()
+	err = session.Start(command)
+	if err != nil {
+		logger.Log("host", s.host, "command", redact.String(command), "error", err)
 		return result, err
 	}
+
+	if input != nil {
+		go func() {
+			io.Copy(input, stdin)
+			input.Close()
+		}()
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- session.Wait() }()
+
+	select {
+	case err = <-done:
+	case <-output.exceeded:
+	case <-ctx.Done():
+		err = fmt.Errorf("%s aborted: %w", command, ctx.Err())
+	}
+	s.recordCommand(time.Since(start))
+
+	result = redact.String(Normalize(output.Bytes(), s.options.Encoding))
+	logged := result
+	if len(logged) > logOutputSize {
+		logged = logged[:logOutputSize] + "..."
+	}
+	logger.Log("host", s.host, "command", redact.String(command), "output", logged, "duration", s.timings.Command, "error", err)
+
+	select {
+	case <-output.exceeded:
+		return result, &errors.OutputTooLargeError{Command: redact.String(command), Limit: limit, Output: result}
+	default:
+	}
+
+	return result, err
+}
+
+// recordCommand keeps the duration of the command that just ran
+func (s *SSHClient) recordCommand(duration time.Duration) {
+	s.commands++
+	s.timings.Command = duration
+	s.timings.Reused = s.commands > 1
+}
+
+// Timings returns the dial and auth time of the connection and the time taken by the last command
+func (s *SSHClient) Timings() devices.Timings {
+	return s.timings
+}
+
+// RunWithConfirmation execute the given command answering yes to the confirmation
+// prompt destructive commands print, instead of hanging waiting for a tty
+func (s *SSHClient) RunWithConfirmation(command string) (result string, err error) {
+	return s.run(context.Background(), command, strings.NewReader("y\n"), s.options.MaxOutputSize)
+}
+
+// RunWithConfirmationContext works as RunWithConfirmation aborting the command when ctx is done
+func (s *SSHClient) RunWithConfirmationContext(ctx context.Context, command string) (result string, err error) {
+	return s.run(ctx, command, strings.NewReader("y\n"), s.options.MaxOutputSize)
+}
+
+// limitedBuffer keeps the output of a command up to the limit, what's written past it
+// is discarded and exceeded is closed to let the caller abort the command
+type limitedBuffer struct {
+	mu       sync.Mutex
+	buffer   bytes.Buffer
+	limit    int
+	exceeded chan struct{}
+	once     sync.Once
+}
+
+// Write implements io.Writer
+func (b *limitedBuffer) Write(p []byte) (n int, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	room := b.limit - b.buffer.Len()
+	if len(p) > room {
+		b.buffer.Write(p[:room])
+		b.once.Do(func() { close(b.exceeded) })
+		return len(p), nil
+	}
+
+	return b.buffer.Write(p)
+}
+
+// Bytes returns a copy of the output kept so far
+func (b *limitedBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]byte(nil), b.buffer.Bytes()...)
+}
+
+// Normalize converts the output of a command into a string using the given encoding,
+// some bmcs print latin1 or garbage in banners and asset tags that would break json serialization
+func Normalize(output []byte, encoding string) (normalized string) {
+	switch encoding {
+	case devices.EncodingUTF8:
+		var b strings.Builder
+		for len(output) > 0 {
+			r, size := utf8.DecodeRune(output)
+			output = output[size:]
+			if r == utf8.RuneError && size == 1 {
+				continue
+			}
+			if isPrintable(r) {
+				b.WriteRune(r)
+			}
+		}
+		return b.String()
+	case devices.EncodingLatin1:
+		var b strings.Builder
+		for _, c := range output {
+			// latin1 maps every byte straight to the unicode code point with the same value
+			if r := rune(c); isPrintable(r) {
+				b.WriteRune(r)
+			}
+		}
+		return b.String()
+	default:
+		return string(output)
+	}
+}
+
+// isPrintable returns false for control characters we don't want to keep in the output
+func isPrintable(r rune) bool {
+	return r == '\n' || r == '\r' || r == '\t' || !unicode.IsControl(r)
+}
+
+// Stream executes the given command and returns a reader with its standard output,
+// the caller is expected to close the reader to release the underlying session
+func (s *SSHClient) Stream(command string) (stream io.ReadCloser, err error) {
+	session, err := s.client.NewSession()
+	if err != nil {
+		return stream, err
+	}
+
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return stream, err
+	}
+
+	err = session.Start(command)
+	if err != nil {
+		session.Close()
+		return stream, err
+	}
+
+	return &sessionReader{Reader: stdout, session: session}, err
+}
+
+// sessionReader ties the lifetime of a ssh session to the reader of its output
+type sessionReader struct {
+	io.Reader
+	session *ssh.Session
+}
+
+// Close closes the session backing the reader
+func (r *sessionReader) Close() (err error) {
+	return r.session.Close()
+}
+
+// Console starts the given interactive command, e.g. the serial console of the bmc, on a terminal
+// and returns its input and output. Closing the console, or ctx being done, writes escape, the
+// sequence detaching from the command, before closing the session so the console isn't left busy
+func (s *SSHClient) Console(ctx context.Context, command string, escape []byte) (console io.ReadWriteCloser, err error) {
+	session, err := s.client.NewSession()
+	if err != nil {
+		return console, err
+	}
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return console, err
+	}
+
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return console, err
+	}
+
+	err = session.RequestPty("vt100", 24, 80, ssh.TerminalModes{ssh.ECHO: 0})
+	if err != nil {
+		session.Close()
+		return console, err
+	}
+
+	err = session.Start(command)
+	if err != nil {
+		session.Close()
+		return console, err
+	}
+
+	c := &sessionConsole{Reader: stdout, stdin: stdin, session: session, escape: escape, closed: make(chan struct{})}
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.Close()
+		case <-c.closed:
+		}
+	}()
+
+	return c, err
+}
+
+// Prompt starts a shell on a terminal and returns what the bmc prints until the output ends with
+// suffix, e.g. the banner and the prompt of its cli. What was read is returned along with io.EOF when
+// the session ends first, or with an error wrapping ctx.Err() when ctx is done first
+func (s *SSHClient) Prompt(ctx context.Context, suffix string) (output string, err error) {
+	session, err := s.client.NewSession()
+	if err != nil {
+		return output, err
+	}
 	defer session.Close()
 
-	output, err := session.CombinedOutput(command)
+	stdout, err := session.StdoutPipe()
 	if err != nil {
-		return string(output), err
+		return output, err
+	}
+
+	err = session.RequestPty("vt100", 24, 80, ssh.TerminalModes{ssh.ECHO: 0})
+	if err != nil {
+		return output, err
+	}
+
+	err = session.Shell()
+	if err != nil {
+		return output, err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	chunks := make(chan []byte)
+	go func() {
+		defer close(chunks)
+		buffer := make([]byte, 1024)
+		for {
+			n, err := stdout.Read(buffer)
+			if n > 0 {
+				select {
+				case chunks <- append([]byte(nil), buffer[:n]...):
+				case <-done:
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	var read bytes.Buffer
+	for {
+		select {
+		case <-ctx.Done():
+			return read.String(), fmt.Errorf("prompt %q not found: %w", suffix, ctx.Err())
+		case chunk, ok := <-chunks:
+			if !ok {
+				return read.String(), io.EOF
+			}
+			read.Write(chunk)
+			if strings.HasSuffix(strings.TrimRight(read.String(), " \t\r\n"), suffix) {
+				return read.String(), err
+			}
+		}
 	}
+}
 
-	return string(output), err
+// sessionConsole ties the lifetime of a ssh session to the console it runs
+type sessionConsole struct {
+	io.Reader
+	stdin   io.WriteCloser
+	session *ssh.Session
+	escape  []byte
+	once    sync.Once
+	closed  chan struct{}
+}
+
+// Write types p on the console
+func (c *sessionConsole) Write(p []byte) (n int, err error) {
+	return c.stdin.Write(p)
+}
+
+// Close detaches from the console and closes the session, it's safe to call it more than once
+func (c *sessionConsole) Close() (err error) {
+	c.once.Do(func() {
+		close(c.closed)
+		if len(c.escape) > 0 {
+			c.stdin.Write(c.escape)
+		}
+		c.stdin.Close()
+		err = c.session.Close()
+	})
+
+	return err
 }
 
 // IsntLetterOrNumber check if the give rune is not a letter nor a number
@@ -69,25 +470,132 @@ func IsntLetterOrNumber(c rune) bool {
 
 // New returns a new configured ssh client
 func New(host string, username string, password string) (connection *SSHClient, err error) {
+	return NewWithOptions(host, username, password, Options{})
+}
+
+// NewWithOptions returns a new ssh client configured with the given options
+func NewWithOptions(host string, username string, password string, options Options) (connection *SSHClient, err error) {
+	return NewWithContext(context.Background(), host, username, password, options)
+}
+
+// NewWithContext returns a new ssh client configured with the given options, the dial and the
+// handshake are aborted when ctx is done, in which case the returned error wraps ctx.Err(), or
+// when they take longer than the timeout, in which case it wraps errors.ErrConnectionTimeout
+func NewWithContext(ctx context.Context, host string, username string, password string, options Options) (connection *SSHClient, err error) {
+	timeout := options.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// tells apart the caller giving up from the connection timing out
+	connectError := func(err error) error {
+		if parent.Err() != nil {
+			return fmt.Errorf("unable to connect to bmc: %w", parent.Err())
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("unable to connect to bmc after %s: %w", timeout, errors.ErrConnectionTimeout)
+		}
+		return fmt.Errorf("unable to connect to bmc: %v", err)
+	}
+
 	if !strings.Contains(host, ":") {
-		host = fmt.Sprintf("%s:22", host)
-	}
-	c, err := ssh.Dial(
-		"tcp",
-		host,
-		&ssh.ClientConfig{
-			User: username,
-			Auth: []ssh.AuthMethod{ssh.Password(password)},
-			HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
-				return nil
-			},
-			Timeout: 15 * time.Second,
+		port := options.Port
+		if port == 0 {
+			port = devices.SSHPort
+		}
+		host = fmt.Sprintf("%s:%d", host, port)
+	}
+
+	auth := []ssh.AuthMethod{ssh.Password(password)}
+	if options.TokenProvider != nil {
+		auth = []ssh.AuthMethod{ssh.KeyboardInteractive(func(user, instruction string, questions []string, echos []bool) (answers []string, err error) {
+			token, err := options.TokenProvider()
+			if err != nil {
+				return answers, err
+			}
+
+			for range questions {
+				answers = append(answers, token)
+			}
+			return answers, err
+		})}
+	}
+
+	if options.Signer != nil {
+		auth = append([]ssh.AuthMethod{ssh.PublicKeys(options.Signer)}, auth...)
+	}
+
+	hostKeyCallback := options.HostKeyCallback
+	if hostKeyCallback == nil {
+		hostKeyWarning.Do(func() {
+			log.WithFields(log.Fields{"step": "bmc connection", "host": host}).Warn("the host keys of the bmcs aren't verified, set a HostKeyCallback to check them")
+		})
+		log.WithFields(log.Fields{"step": "bmc connection", "host": host}).Debug("the host key of the bmc isn't verified")
+		hostKeyCallback = ssh.InsecureIgnoreHostKey()
+	}
+
+	config := &ssh.ClientConfig{
+		Config: ssh.Config{
+			Ciphers:      options.Ciphers,
+			KeyExchanges: options.KeyExchanges,
+			MACs:         options.MACs,
 		},
-	)
+		User:            username,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         timeout,
+	}
+
+	// dial and handshake are done separately to tell apart a slow network from a slow bmc
+	var timings devices.Timings
+	start := time.Now()
+	dialer := net.Dialer{Timeout: config.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", host)
 	if err != nil {
-		return connection, fmt.Errorf("unable to connect to bmc: %v", err)
+		if e, ok := err.(net.Error); ok && e.Timeout() && parent.Err() == nil {
+			return connection, fmt.Errorf("unable to connect to bmc after %s: %w", timeout, errors.ErrConnectionTimeout)
+		}
+		return connection, connectError(err)
 	}
-	return &SSHClient{c}, err
+	timings.Dial = time.Since(start)
+
+	// the handshake has no context of its own, the connection is closed under it instead
+	handshake := make(chan struct{})
+	watcher := make(chan struct{})
+	go func() {
+		defer close(watcher)
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-handshake:
+		}
+	}()
+
+	start = time.Now()
+	c, chans, reqs, err := ssh.NewClientConn(conn, host, config)
+	close(handshake)
+	<-watcher
+	if err == nil && ctx.Err() != nil {
+		c.Close()
+		err = ctx.Err()
+	}
+	if err != nil {
+		conn.Close()
+		return connection, connectError(err)
+	}
+	timings.Auth = time.Since(start)
+
+	return &SSHClient{client: ssh.NewClient(c, chans, reqs), host: host, options: options, timings: timings}, err
+}
+
+// Alive tells if the connection still answers, bmcs drop idle sessions without notice
+func (s *SSHClient) Alive() bool {
+	_, _, err := s.client.SendRequest("keepalive@openssh.com", true, nil)
+	return err == nil
 }
 
 // Close closed the ssh connection and ensure to always exit, some vendors will have issues with the bmc if you dont do it
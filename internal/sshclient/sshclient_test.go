@@ -0,0 +1,103 @@
+package sshclient
+
+import (
+	goerrors "errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bmc-toolbox/bmclib/devices"
+	"github.com/bmc-toolbox/bmclib/errors"
+)
+
+func TestNormalize(t *testing.T) {
+	tt := []struct {
+		name     string
+		encoding string
+		output   []byte
+		expected string
+	}{
+		{"raw", devices.EncodingRaw, []byte("Asset Tag: caf\xe9\x07\n"), "Asset Tag: caf\xe9\x07\n"},
+		{"utf8", devices.EncodingUTF8, []byte("Asset Tag: caf\xe9\x07\n"), "Asset Tag: caf\n"},
+		{"utf8 valid", devices.EncodingUTF8, []byte("Asset Tag: café\r\n"), "Asset Tag: café\r\n"},
+		{"latin1", devices.EncodingLatin1, []byte("Asset Tag: caf\xe9\x07\n"), "Asset Tag: café\n"},
+	}
+
+	for _, tc := range tt {
+		answer := Normalize(tc.output, tc.encoding)
+		if answer != tc.expected {
+			t.Errorf("%s: Expected answer %q: found %q", tc.name, tc.expected, answer)
+		}
+	}
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	expectedAnswer := "RAC Infor"
+
+	output := &limitedBuffer{limit: len(expectedAnswer), exceeded: make(chan struct{})}
+	for _, chunk := range []string{"RAC ", "Information:\n", "garbage"} {
+		n, err := output.Write([]byte(chunk))
+		if err != nil || n != len(chunk) {
+			t.Fatalf("Found errors writing %q: %d %v", chunk, n, err)
+		}
+	}
+
+	select {
+	case <-output.exceeded:
+	default:
+		t.Errorf("Expected the limit to be exceeded")
+	}
+
+	answer := string(output.Bytes())
+	if answer != expectedAnswer {
+		t.Errorf("Expected answer %q: found %q", expectedAnswer, answer)
+	}
+}
+
+func TestNewWithOptionsTimeout(t *testing.T) {
+	timeout := 200 * time.Millisecond
+
+	// accepts the connection but never starts the ssh handshake, like a wedged bmc
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+	defer listener.Close()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	start := time.Now()
+	_, err = NewWithOptions(listener.Addr().String(), "super", "test", Options{Timeout: timeout})
+	if !goerrors.Is(err, errors.ErrConnectionTimeout) {
+		t.Errorf("Expected answer %v: found %v", errors.ErrConnectionTimeout, err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*timeout {
+		t.Errorf("Expected the connection to give up after %s: found %s", timeout, elapsed)
+	}
+
+	// nothing listens on a closed port, the connection is refused right away
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+	closed.Close()
+
+	start = time.Now()
+	_, err = NewWithOptions(closed.Addr().String(), "super", "test", Options{Timeout: timeout})
+	if err == nil {
+		t.Errorf("Expected an error connecting to a closed port")
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*timeout {
+		t.Errorf("Expected the connection to give up after %s: found %s", timeout, elapsed)
+	}
+}
@@ -0,0 +1,47 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the name of the OpenTelemetry tracer used by bmclib
+const TracerName = "github.com/bmc-toolbox/bmclib"
+
+// Start opens a span named after the vendor and the action run against the given host,
+// it's a no-op unless a tracer provider was registered with otel.SetTracerProvider
+func Start(ctx context.Context, vendor string, action string, host string) trace.Span {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	_, span := otel.Tracer(TracerName).Start(ctx, fmt.Sprintf("%s.%s", vendor, action), trace.WithAttributes(
+		attribute.String("bmc.vendor", vendor),
+		attribute.String("bmc.host", host),
+	))
+	return span
+}
+
+// Parent returns the context the span of an action is started from, the per call ctx of the
+// *WithContext methods when it carries a span and fallback, the trace context of the device, otherwise
+func Parent(ctx context.Context, fallback context.Context) context.Context {
+	if ctx != nil && trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+
+	return fallback
+}
+
+// End marks the span as failed when the action returned an error and closes it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -3,13 +3,16 @@ package logging
 import (
 	"io"
 	"os"
+	"regexp"
 
+	"github.com/bmc-toolbox/bmclib/internal/redact"
 	log "github.com/sirupsen/logrus"
 )
 
 func init() {
 	log.SetFormatter(&log.JSONFormatter{})
 	log.SetOutput(os.Stdout)
+	log.AddHook(&redactHook{})
 
 	switch os.Getenv("DEBUG_BMCLIB") {
 	case "1":
@@ -33,3 +36,32 @@ func SetOutput(out io.Writer) {
 func SetLevel(level log.Level) {
 	log.SetLevel(level)
 }
+
+// AddRedactPattern registers an extra secret pattern to be masked from the command output,
+// the returned errors and the log entries. The secret is expected in the second group
+func AddRedactPattern(pattern *regexp.Regexp) {
+	redact.Add(pattern)
+}
+
+// redactHook masks the known secrets from the log entries before they are written
+type redactHook struct{}
+
+// Levels returns the levels the hook applies to, all of them
+func (h *redactHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+// Fire masks the secrets found in the message and the string fields of the entry
+func (h *redactHook) Fire(entry *log.Entry) error {
+	entry.Message = redact.String(entry.Message)
+	for key, value := range entry.Data {
+		switch v := value.(type) {
+		case string:
+			entry.Data[key] = redact.String(v)
+		case error:
+			entry.Data[key] = redact.String(v.Error())
+		}
+	}
+
+	return nil
+}
@@ -1,122 +1,962 @@
 package idrac8
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/bmc-toolbox/bmclib/devices"
+	"github.com/bmc-toolbox/bmclib/errors"
+	"github.com/bmc-toolbox/bmclib/internal/helper"
+	"github.com/bmc-toolbox/bmclib/internal/ipmi"
+	"github.com/bmc-toolbox/bmclib/internal/redact"
+	"github.com/bmc-toolbox/bmclib/internal/tracing"
+	"github.com/bmc-toolbox/bmclib/providers/dell"
+
+	log "github.com/sirupsen/logrus"
 )
 
 // PowerCycle reboots the machine via bmc
 func (i *IDrac8) PowerCycle() (status bool, err error) {
-	err = i.sshLogin()
+	return i.PowerCycleWithContext(context.Background())
+}
+
+// PowerCycleWithContext works as PowerCycle giving up when ctx is done, the returned error wraps ctx.Err() then
+func (i *IDrac8) PowerCycleWithContext(ctx context.Context) (status bool, err error) {
+	_, status, err = i.powerAction(ctx, "PowerCycle", "racadm serveraction hardreset", "successful")
+	return status, err
+}
+
+// PowerCycleVerbose works as PowerCycle also returning what the bmc printed, for the audit logs
+func (i *IDrac8) PowerCycleVerbose() (output string, status bool, err error) {
+	return i.powerAction(context.Background(), "PowerCycle", "racadm serveraction hardreset", "successful")
+}
+
+// PowerCycleWith reboots the machine via bmc using the given reset type,
+// a cold reset power cycles the server while a warm reset is the same as PowerCycle
+func (i *IDrac8) PowerCycleWith(resetType devices.ResetType) (status bool, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "PowerCycleWith", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	var command string
+	switch resetType {
+	case devices.ResetWarm:
+		command = "racadm serveraction hardreset"
+	case devices.ResetCold:
+		command = "racadm serveraction powercycle"
+	default:
+		return status, errors.ErrFeatureUnavailable
+	}
+
+	err = i.sshLoginRW()
 	if err != nil {
 		return status, err
 	}
 
-	output, err := i.sshClient.Run("racadm serveraction hardreset")
+	output, err := i.run(command)
 	if err != nil {
-		return false, fmt.Errorf(output)
+		return false, &errors.CommandError{Cmd: command, Output: output, Err: err}
 	}
-	if strings.Contains(output, "successful") {
+	if i.succeeded("PowerCycleWith", output, "successful") {
 		return true, err
 	}
 
-	return status, fmt.Errorf(output)
+	return status, &errors.CommandError{Cmd: command, Output: output, Err: err}
 }
 
 // PowerCycleBmc reboots the bmc we are connected to
 func (i *IDrac8) PowerCycleBmc() (status bool, err error) {
-	err = i.sshLogin()
+	return i.PowerCycleBmcWithContext(context.Background())
+}
+
+// PowerCycleBmcWithContext works as PowerCycleBmc giving up when ctx is done
+func (i *IDrac8) PowerCycleBmcWithContext(ctx context.Context) (status bool, err error) {
+	_, status, err = i.powerAction(ctx, "PowerCycleBmc", "racadm racreset hard", "initiated successfully")
+	return status, err
+}
+
+// PowerCycleBmcVerbose works as PowerCycleBmc also returning what the bmc printed
+func (i *IDrac8) PowerCycleBmcVerbose() (output string, status bool, err error) {
+	return i.powerAction(context.Background(), "PowerCycleBmc", "racadm racreset hard", "initiated successfully")
+}
+
+// PowerOn power on the machine via bmc
+func (i *IDrac8) PowerOn() (status bool, err error) {
+	return i.PowerOnWithContext(context.Background())
+}
+
+// PowerOnWithContext works as PowerOn giving up when ctx is done
+func (i *IDrac8) PowerOnWithContext(ctx context.Context) (status bool, err error) {
+	_, status, err = i.powerAction(ctx, "PowerOn", "racadm serveraction powerup", "successful")
+	return status, err
+}
+
+// PowerOnVerbose works as PowerOn also returning what the bmc printed
+func (i *IDrac8) PowerOnVerbose() (output string, status bool, err error) {
+	return i.powerAction(context.Background(), "PowerOn", "racadm serveraction powerup", "successful")
+}
+
+// PowerOff powers off the machine via bmc asking the operating system to shutdown first,
+// the power is cut as PowerOffForce does when the bmc doesn't accept the graceful shutdown
+func (i *IDrac8) PowerOff() (status bool, err error) {
+	return i.PowerOffWithContext(context.Background())
+}
+
+// PowerOffWithContext works as PowerOff giving up when ctx is done
+func (i *IDrac8) PowerOffWithContext(ctx context.Context) (status bool, err error) {
+	_, status, err = i.powerOff(ctx)
+	return status, err
+}
+
+// PowerOffVerbose works as PowerOff also returning what the bmc printed, the answer to the
+// forced power off when the graceful shutdown was rejected
+func (i *IDrac8) PowerOffVerbose() (output string, status bool, err error) {
+	return i.powerOff(context.Background())
+}
+
+func (i *IDrac8) powerOff(ctx context.Context) (output string, status bool, err error) {
+	output, status, err = i.powerAction(ctx, "PowerOff", "racadm serveraction graceshutdown", "successful")
+	// only a graceful shutdown the bmc answered and refused is forced, a transport
+	// or exit failure doesn't tell whether the command reached the bmc
+	if rejected, ok := err.(*errors.CommandError); !ok || rejected.Err != nil || output == "" {
+		return output, status, err
+	}
+
+	log.WithFields(log.Fields{"step": helper.WhosCalling(), "IP": i.ip, "Model": i.BmcType(), "output": output}).Debug("Graceful shutdown rejected, forcing the power off.")
+	return i.powerAction(ctx, "PowerOffForce", "racadm serveraction powerdown", "successful")
+}
+
+// PowerOffForce cuts the power of the machine via bmc without waiting for the operating system
+func (i *IDrac8) PowerOffForce() (status bool, err error) {
+	return i.PowerOffForceWithContext(context.Background())
+}
+
+// PowerOffForceWithContext works as PowerOffForce giving up when ctx is done
+func (i *IDrac8) PowerOffForceWithContext(ctx context.Context) (status bool, err error) {
+	_, status, err = i.powerAction(ctx, "PowerOffForce", "racadm serveraction powerdown", "successful")
+	return status, err
+}
+
+// powerAction runs the power command returning what the bmc printed, status tells whether
+// it's the expected answer
+func (i *IDrac8) powerAction(ctx context.Context, action string, command string, expected string) (output string, status bool, err error) {
+	span := tracing.Start(tracing.Parent(ctx, i.traceCtx), dell.VendorID, action, i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLoginRWContext(ctx)
 	if err != nil {
-		return status, err
+		return output, status, err
 	}
 
-	output, err := i.sshClient.Run("racadm racreset hard")
+	output, err = i.runContext(ctx, command)
 	if err != nil {
-		return false, fmt.Errorf(output)
+		if ctx.Err() != nil {
+			return output, false, err
+		}
+		return output, false, &errors.CommandError{Cmd: command, Output: output, Err: err}
 	}
-	if strings.Contains(output, "initiated successfully") {
-		return true, err
+
+	if i.succeeded(action, output, expected) {
+		return output, true, err
 	}
 
-	return status, fmt.Errorf(output)
+	return output, status, &errors.CommandError{Cmd: command, Output: output, Err: err}
 }
 
-// PowerOn power on the machine via bmc
-func (i *IDrac8) PowerOn() (status bool, err error) {
-	err = i.sshLogin()
+// PowerOffSoft asks the operating system to shutdown cleanly via bmc, unlike PowerOff
+// the power is never forced off when the bmc doesn't accept it
+func (i *IDrac8) PowerOffSoft() (status bool, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "PowerOffSoft", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLoginRW()
 	if err != nil {
 		return status, err
 	}
 
-	output, err := i.sshClient.Run("racadm serveraction powerup")
+	command := "racadm serveraction graceshutdown"
+	output, err := i.run(command)
 	if err != nil {
-		return false, fmt.Errorf(output)
+		return false, &errors.CommandError{Cmd: command, Output: output, Err: err}
 	}
 
-	if strings.Contains(output, "successful") {
+	if i.succeeded("PowerOffSoft", output, "successful") {
 		return true, err
 	}
 
-	return status, fmt.Errorf(output)
+	return status, &errors.CommandError{Cmd: command, Output: output, Err: err}
 }
 
-// PowerOff power off the machine via bmc
-func (i *IDrac8) PowerOff() (status bool, err error) {
-	err = i.sshLogin()
+// PressPowerButton emulates a press of the power button, a held press forces the machine
+// off while a momentary press signals the operating system to shutdown
+func (i *IDrac8) PressPowerButton(hold bool) (status bool, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "PressPowerButton", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLoginRW()
 	if err != nil {
 		return status, err
 	}
 
-	output, err := i.sshClient.Run("racadm serveraction powerdown")
+	command := "racadm serveraction graceshutdown"
+	if hold {
+		command = "racadm serveraction powerdown"
+	}
+
+	output, err := i.run(command)
 	if err != nil {
-		return false, fmt.Errorf(output)
+		return false, &errors.CommandError{Cmd: command, Output: output, Err: err}
 	}
 
-	if strings.Contains(output, "successful") {
+	if i.succeeded("PressPowerButton", output, "successful") {
 		return true, err
 	}
 
-	return status, fmt.Errorf(output)
+	return status, &errors.CommandError{Cmd: command, Output: output, Err: err}
 }
 
 // PxeOnce makes the machine to boot via pxe once
 func (i *IDrac8) PxeOnce() (status bool, err error) {
-	err = i.sshLogin()
+	span := tracing.Start(i.traceCtx, dell.VendorID, "PxeOnce", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLoginRW()
 	if err != nil {
 		return status, err
 	}
 
-	output, err := i.sshClient.Run("racadm config -g cfgServerInfo -o cfgServerBootOnce 1")
+	command := "racadm config -g cfgServerInfo -o cfgServerBootOnce 1"
+	output, err := i.run(command)
 	if err != nil {
-		return false, fmt.Errorf(output)
+		return false, &errors.CommandError{Cmd: command, Output: output, Err: err}
 	}
-	if strings.Contains(output, "successful") {
-		output, err = i.sshClient.Run("racadm config -g cfgServerInfo -o cfgServerFirstBootDevice PXE")
+	if i.succeeded("PxeOnce", output, "successful") {
+		command = "racadm config -g cfgServerInfo -o cfgServerFirstBootDevice PXE"
+		output, err = i.run(command)
 		if err != nil {
-			return false, fmt.Errorf(output)
+			return false, &errors.CommandError{Cmd: command, Output: output, Err: err}
 		}
-		if strings.Contains(output, "successful") {
+		if i.succeeded("PxeOnce", output, "successful") {
 			return i.PowerCycle()
 		}
 	}
 
-	return status, fmt.Errorf(output)
+	return status, &errors.CommandError{Cmd: command, Output: output, Err: err}
 }
 
 // IsOn tells if a machine is currently powered on
 func (i *IDrac8) IsOn() (status bool, err error) {
-	err = i.sshLogin()
+	span := tracing.Start(i.traceCtx, dell.VendorID, "IsOn", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	state, err := i.PowerState()
 	if err != nil {
 		return status, err
 	}
 
-	output, err := i.sshClient.Run("racadm serveraction powerstatus")
+	return state == devices.PowerStateOn, err
+}
+
+// ResetRecoveryCounters isn't supported, the ASR and NMI counters are derived from the SEL
+// and can only be reset clearing the whole event log with ClearSEL
+func (i *IDrac8) ResetRecoveryCounters() (status bool, err error) {
+	return status, errors.ErrFeatureUnavailable
+}
+
+// ClearSEL clears the System Event Log, clearing a log that's already empty succeeds as well
+func (i *IDrac8) ClearSEL() (status bool, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "ClearSEL", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLoginRW()
 	if err != nil {
-		return false, fmt.Errorf("%v: %v", err, output)
+		return status, err
+	}
+
+	command := "racadm clrsel"
+	output, err := i.run(command)
+	if strings.Contains(strings.ToLower(output), "already empty") {
+		return true, nil
 	}
-	if strings.Contains(output, "Server power status: ON") {
+	if err != nil {
+		return false, &errors.CommandError{Cmd: command, Output: output, Err: err}
+	}
+
+	if i.succeeded("ClearSEL", output, "successful") {
 		return true, err
 	}
 
-	return status, fmt.Errorf(output)
+	return status, &errors.CommandError{Cmd: command, Output: output, Err: err}
+}
+
+// ResetConfig resets the bmc configuration to the factory defaults, the bmc reboots afterwards.
+// It only wipes the configuration when confirm is true, so a zero value can't decommission a bmc by accident
+func (i *IDrac8) ResetConfig(confirm bool) (status bool, err error) {
+	if !confirm {
+		return status, errors.ErrNotConfirmed
+	}
+
+	span := tracing.Start(i.traceCtx, dell.VendorID, "ResetConfig", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLoginRW()
+	if err != nil {
+		return status, err
+	}
+
+	command := "racadm racresetcfg"
+	output, err := i.run(command)
+	if err != nil {
+		return false, &errors.CommandError{Cmd: command, Output: output, Err: err}
+	}
+
+	if i.succeeded("ResetConfig", output, "successful") {
+		return true, err
+	}
+
+	return status, &errors.CommandError{Cmd: command, Output: output, Err: err}
+}
+
+// DeleteJob removes the given job from the job queue, JID_CLEARALL removes all of them
+func (i *IDrac8) DeleteJob(jobID string) (status bool, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "DeleteJob", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLoginRW()
+	if err != nil {
+		return status, err
+	}
+
+	command := fmt.Sprintf("racadm jobqueue delete -i %s", jobID)
+	output, err := i.run(command)
+	if err != nil {
+		return false, &errors.CommandError{Cmd: command, Output: output, Err: err}
+	}
+
+	if i.succeeded("DeleteJob", output, "RAC1032") {
+		return true, err
+	}
+
+	return status, &errors.CommandError{Cmd: command, Output: output, Err: err}
+}
+
+// ClearJobQueue removes every job from the job queue, the stuck ones blocking the new jobs
+// included. An empty job queue is left as is
+func (i *IDrac8) ClearJobQueue() (status bool, err error) {
+	status, err = i.DeleteJob("JID_CLEARALL")
+	if rejected, ok := err.(*errors.CommandError); ok && strings.Contains(strings.ToLower(rejected.Output), "no job") {
+		return true, nil
+	}
+
+	return status, err
+}
+
+// CancelJob cancels the given job removing it from the job queue, firmware updates
+// can't be cancelled anymore once they started flashing the component
+func (i *IDrac8) CancelJob(jobID string) (status bool, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "CancelJob", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLoginRW()
+	if err != nil {
+		return status, err
+	}
+
+	// the job can't be looked up in dry run, there's nothing to tell whether it can be cancelled
+	if i.dryRun {
+		return i.DeleteJob(jobID)
+	}
+
+	// racadm fails when the job doesn't exist, which is told apart by the output
+	command := fmt.Sprintf("racadm jobqueue view -i %s", jobID)
+	output, err := i.run(command)
+	job := parseRacadmFields(output)
+	if job["Job ID"] != jobID {
+		if jobNotFound(output) {
+			return false, errors.ErrJobNotFound
+		}
+		return false, &errors.CommandError{Cmd: command, Output: output, Err: err}
+	}
+
+	if strings.HasPrefix(job["Job Name"], "Firmware Update") {
+		switch job["Status"] {
+		case "Running", "Installing", "Installed", "Reboot Pending":
+			return false, errors.ErrJobNotCancellable
+		}
+	}
+
+	return i.DeleteJob(jobID)
+}
+
+// CreateUser creates the user account in the first free user slot and enables it, role is
+// one of admin or user. It fails when the account already exists or every slot is used
+func (i *IDrac8) CreateUser(username string, password string, role string) (status bool, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "CreateUser", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	if username == "" {
+		return status, fmt.Errorf("username is required")
+	}
+
+	if !isPasswordValid(password) {
+		return status, fmt.Errorf("invalid password for %s: it can't be empty nor contain spaces or quotes", username)
+	}
+
+	if !isRoleValid(role) {
+		return status, fmt.Errorf("role expected to be one of 'admin', 'user'")
+	}
+
+	err = i.sshLoginRW()
+	if err != nil {
+		return status, err
+	}
+
+	index, err := i.freeUserAdminIndex(username)
+	if err != nil {
+		return status, err
+	}
+
+	privilege, _ := rolePrivileges(role)
+	mask, err := strconv.Atoi(privilege)
+	if err != nil {
+		return status, err
+	}
+
+	for _, object := range []struct{ name, value string }{
+		{"cfgUserAdminUserName", username},
+		{"cfgUserAdminPassword", password},
+		{"cfgUserAdminPrivilege", fmt.Sprintf("0x%08x", mask)},
+		{"cfgUserAdminEnable", "1"},
+	} {
+		err = i.setUserAdmin("CreateUser", index, object.name, object.value)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return true, err
+}
+
+// DeleteUser disables the given user account and clears its user slot, errors.ErrUserNotFound
+// is returned when the account doesn't exist
+func (i *IDrac8) DeleteUser(username string) (status bool, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "DeleteUser", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	if username == "" {
+		return status, fmt.Errorf("username is required")
+	}
+
+	err = i.sshLoginRW()
+	if err != nil {
+		return status, err
+	}
+
+	index, err := i.userAdminIndex(username)
+	if err != nil {
+		return status, err
+	}
+
+	for _, object := range []struct{ name, value string }{
+		{"cfgUserAdminEnable", "0"},
+		{"cfgUserAdminPrivilege", "0x00000000"},
+		{"cfgUserAdminUserName", `""`},
+	} {
+		err = i.setUserAdmin("DeleteUser", index, object.name, object.value)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return true, err
+}
+
+// UpdatePassword sets a new password to the given user account, errors.ErrUserNotFound is returned
+// when the account doesn't exist
+func (i *IDrac8) UpdatePassword(username string, password string) (status bool, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "UpdatePassword", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	if username == "" {
+		return status, fmt.Errorf("username is required")
+	}
+
+	if !isPasswordValid(password) {
+		return status, fmt.Errorf("invalid password for %s: it can't be empty nor contain spaces or quotes", username)
+	}
+
+	err = i.sshLoginRW()
+	if err != nil {
+		return status, err
+	}
+
+	index, err := i.userAdminIndex(username)
+	if err != nil {
+		return status, err
+	}
+
+	err = i.setUserAdmin("UpdatePassword", index, "cfgUserAdminPassword", password)
+	if err != nil {
+		return false, err
+	}
+
+	return true, err
+}
+
+// userAdminIndex returns the user slot holding the given user account
+func (i *IDrac8) userAdminIndex(username string) (index int, err error) {
+	if i.dryRun {
+		return dryRunUserSlot, err
+	}
+
+	users, err := i.userAdminSlots()
+	if err != nil {
+		return index, err
+	}
+
+	for slot, name := range users {
+		if slot > 1 && name == username {
+			return slot, err
+		}
+	}
+
+	return index, errors.ErrUserNotFound
+}
+
+// freeUserAdminIndex returns the first free user slot to create the given user account in,
+// it fails when the account already exists or every slot is used
+func (i *IDrac8) freeUserAdminIndex(username string) (index int, err error) {
+	if i.dryRun {
+		return dryRunUserSlot, err
+	}
+
+	users, err := i.userAdminSlots()
+	if err != nil {
+		return index, err
+	}
+
+	for slot := 2; slot <= userSlots; slot++ {
+		name, listed := users[slot]
+		if name == username {
+			return 0, fmt.Errorf("user %s already exists in slot %d", username, slot)
+		}
+
+		if listed && name == "" && index == 0 {
+			index = slot
+		}
+	}
+
+	if index == 0 {
+		return index, fmt.Errorf("unable to create user %s, all the %d user slots are used", username, userSlots)
+	}
+
+	return index, err
+}
+
+// userAdminSlots returns the user names held by the user slots listed by racadm getconfig -g cfgUserAdmin
+func (i *IDrac8) userAdminSlots() (users map[int]string, err error) {
+	command := "racadm getconfig -g cfgUserAdmin"
+	output, err := i.run(command)
+	if err != nil {
+		return users, &errors.CommandError{Cmd: command, Output: output, Err: err}
+	}
+
+	return parseUserAdmin(output), err
+}
+
+// setUserAdmin sets an object of the cfgUserAdmin group of the given user slot
+func (i *IDrac8) setUserAdmin(action string, index int, object string, value string) (err error) {
+	command := fmt.Sprintf("racadm config -g cfgUserAdmin -o %s -i %d %s", object, index, value)
+	output, err := i.run(command)
+	if err != nil {
+		return &errors.CommandError{Cmd: redact.String(command), Output: output, Err: err}
+	}
+
+	if !i.succeeded(action, output, "successfully") {
+		return &errors.CommandError{Cmd: redact.String(command), Output: output, Err: err}
+	}
+
+	return err
+}
+
+// SetBootDevice defines the device the machine boots from, one of pxe, disk, bios or cdrom. When persistent
+// isn't set it only applies to the next boot, the machine isn't rebooted
+func (i *IDrac8) SetBootDevice(device string, persistent bool) (status bool, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "SetBootDevice", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	value, ok := bootDevices[device]
+	if !ok {
+		return status, fmt.Errorf("unknown boot device: %s, expected one of pxe, disk, bios or cdrom", device)
+	}
+
+	err = i.sshLoginRW()
+	if err != nil {
+		return status, err
+	}
+
+	bootOnce := 1
+	if persistent {
+		bootOnce = 0
+	}
+
+	for _, command := range []string{
+		fmt.Sprintf("racadm config -g cfgServerInfo -o cfgServerBootOnce %d", bootOnce),
+		fmt.Sprintf("racadm config -g cfgServerInfo -o cfgServerFirstBootDevice %s", value),
+	} {
+		output, err := i.run(command)
+		if err != nil {
+			return false, &errors.CommandError{Cmd: command, Output: output, Err: err}
+		}
+
+		if !i.succeeded("SetBootDevice", output, "successful") {
+			return false, &errors.CommandError{Cmd: command, Output: output, Err: err}
+		}
+	}
+
+	return true, err
+}
+
+// SetNetwork sets the static ipv4 address, netmask and gateway of the idrac, disabling dhcp. They
+// are applied together by racadm setniccfg, disabling dhcp alone would move the idrac to its stored
+// static address first. The idrac drops the ssh session once the address changes, the dropped
+// session is taken as the expected answer and closed, the idrac has to be reached at the new address afterwards
+func (i *IDrac8) SetNetwork(ip string, netmask string, gateway string) (status bool, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "SetNetwork", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = validateNetwork(ip, netmask, gateway)
+	if err != nil {
+		return status, err
+	}
+
+	err = i.sshLoginRW()
+	if err != nil {
+		return status, err
+	}
+
+	command := fmt.Sprintf("racadm setniccfg -s %s %s %s", ip, netmask, gateway)
+	output, err := i.run(command)
+	if sessionDropped(err) {
+		log.WithFields(log.Fields{"step": helper.WhosCalling(), "IP": i.ip, "Model": i.BmcType(), "address": ip}).Debug("Session dropped changing the ip address.")
+		i.closeSSH()
+		return true, nil
+	}
+	if err != nil {
+		return false, &errors.CommandError{Cmd: command, Output: output, Err: err}
+	}
+
+	if !i.succeeded("SetNetwork", output, "successfully") {
+		return false, &errors.CommandError{Cmd: command, Output: output, Err: err}
+	}
+
+	i.closeSSH()
+	return true, err
+}
+
+// SetNTP makes the idrac sync its clock with up to two ntp servers and sets its timezone, e.g. UTC
+// or Europe/Amsterdam. A second server left from a previous setup is kept when a single one is given
+func (i *IDrac8) SetNTP(servers []string, timezone string) (status bool, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "SetNTP", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	if len(servers) == 0 || len(servers) > 2 {
+		return status, fmt.Errorf("expected one or two ntp servers, found %d", len(servers))
+	}
+
+	commands := []string{}
+	for n, server := range servers {
+		if !validHost(server) {
+			return status, fmt.Errorf("invalid ntp server: %q", server)
+		}
+		commands = append(commands, fmt.Sprintf("racadm set iDRAC.NTPConfigGroup.NTP%d %s", n+1, server))
+	}
+
+	if timezone == "" || strings.ContainsAny(timezone, " \t") {
+		return status, fmt.Errorf("invalid timezone: %q", timezone)
+	}
+
+	commands = append(commands,
+		"racadm set iDRAC.NTPConfigGroup.NTPEnable Enabled",
+		fmt.Sprintf("racadm set iDRAC.Time.Timezone %s", timezone),
+	)
+
+	err = i.sshLoginRW()
+	if err != nil {
+		return status, err
+	}
+
+	for _, command := range commands {
+		output, err := i.run(command)
+		if err != nil {
+			return false, &errors.CommandError{Cmd: command, Output: output, Err: err}
+		}
+
+		if !i.succeeded("SetNTP", output, "successful") {
+			return false, &errors.CommandError{Cmd: command, Output: output, Err: err}
+		}
+	}
+
+	return true, err
+}
+
+// UpdateFirmware flashes the Dell update package (.exe) or the idrac firmware image (.d9) at the given
+// http(s) url, racadm downloads it and queues the update. The update is followed with JobStatus
+// and the returned job id
+func (i *IDrac8) UpdateFirmware(firmwareURL string) (jobID string, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "UpdateFirmware", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	file, location, transport, err := firmwareSource(firmwareURL)
+	if err != nil {
+		return jobID, err
+	}
+
+	err = i.sshLoginRW()
+	if err != nil {
+		return jobID, err
+	}
+
+	command := fmt.Sprintf("racadm update -f %s -e %s -t %s", file, location, transport)
+	output, err := i.run(command)
+	if err != nil {
+		return jobID, &errors.CommandError{Cmd: command, Output: output, Err: err}
+	}
+
+	// the job is only created when the command reaches the bmc, no job id is returned in dry run
+	if i.dryRun {
+		return jobID, err
+	}
+
+	jobID = jobIDPattern.FindString(output)
+	if jobID == "" {
+		return jobID, &errors.CommandError{Cmd: command, Output: output}
+	}
+
+	return jobID, err
+}
+
+// MountISO attaches the iso image at the given http, https, nfs or cifs url as the remote file share,
+// the credentials of cifs shares are taken from the url. It fails when an image is already attached,
+// UnmountISO has to be called first
+func (i *IDrac8) MountISO(isoURL string) (status bool, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "MountISO", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	share, username, password, err := remoteImageShare(isoURL)
+	if err != nil {
+		return status, err
+	}
+
+	err = i.sshLoginRW()
+	if err != nil {
+		return status, err
+	}
+
+	// the password is left out of the command reported with the errors
+	command := fmt.Sprintf("racadm remoteimage -c -l %s", share)
+	cmdline := command
+	if username != "" {
+		command = fmt.Sprintf("racadm remoteimage -c -u %s -p ******** -l %s", username, share)
+		cmdline = fmt.Sprintf("racadm remoteimage -c -u %s -p %s -l %s", username, password, share)
+	}
+
+	output, err := i.run(cmdline)
+	if answer := strings.ToLower(output); strings.Contains(answer, "already connected") || strings.Contains(answer, "already in use") {
+		return false, fmt.Errorf("a remote image is already connected to %s, call UnmountISO first: %s", i.ip, strings.TrimSpace(output))
+	}
+	if err != nil {
+		return false, &errors.CommandError{Cmd: command, Output: output, Err: err}
+	}
+
+	if i.succeeded("MountISO", output, "Remote Image is now Configured") {
+		return true, err
+	}
+
+	return status, &errors.CommandError{Cmd: command, Output: output, Err: err}
+}
+
+// UnmountISO detaches the image attached with MountISO
+func (i *IDrac8) UnmountISO() (status bool, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "UnmountISO", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLoginRW()
+	if err != nil {
+		return status, err
+	}
+
+	command := "racadm remoteimage -d"
+	output, err := i.run(command)
+	if err != nil {
+		return false, &errors.CommandError{Cmd: command, Output: output, Err: err}
+	}
+
+	if i.succeeded("UnmountISO", output, "Disable Remote File Started") {
+		return true, err
+	}
+
+	return status, &errors.CommandError{Cmd: command, Output: output, Err: err}
+}
+
+// SetIdentifyLED makes the identify led of the chassis blink, or stops it, to find the machine in the rack
+func (i *IDrac8) SetIdentifyLED(on bool) (status bool, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "SetIdentifyLED", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLoginRW()
+	if err != nil {
+		return status, err
+	}
+
+	state := 0
+	if on {
+		state = 1
+	}
+
+	command := fmt.Sprintf("racadm setled -l %d", state)
+	output, err := i.run(command)
+	if err != nil {
+		return false, &errors.CommandError{Cmd: command, Output: output, Err: err}
+	}
+
+	if i.succeeded("SetIdentifyLED", output, "successfully") {
+		return true, err
+	}
+
+	return status, &errors.CommandError{Cmd: command, Output: output, Err: err}
+}
+
+// SetBMCTime sets the bmc clock to the given time
+func (i *IDrac8) SetBMCTime(t time.Time) (status bool, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "SetBMCTime", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLoginRW()
+	if err != nil {
+		return status, err
+	}
+
+	command := fmt.Sprintf("racadm setractime -d %s+000", t.UTC().Format(racTimeFormat))
+	output, err := i.run(command)
+	if err != nil {
+		return false, &errors.CommandError{Cmd: command, Output: output, Err: err}
+	}
+
+	if i.succeeded("SetBMCTime", output, "successful") {
+		return true, err
+	}
+
+	return status, &errors.CommandError{Cmd: command, Output: output, Err: err}
+}
+
+// SetPowerRestorePolicy defines the power state the machine goes to when the AC power comes back,
+// being a bios setting it's applied by a job that runs on the next reboot
+func (i *IDrac8) SetPowerRestorePolicy(policy devices.PowerRestorePolicy) (status bool, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "SetPowerRestorePolicy", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	value, ok := biosRestorePolicies[policy]
+	if !ok {
+		return status, fmt.Errorf("unknown power restore policy: %s", policy)
+	}
+
+	err = i.sshLoginRW()
+	if err != nil {
+		return status, err
+	}
+
+	command := fmt.Sprintf("racadm set BIOS.SysSecurity.AcPwrRcvry %s", value)
+	output, err := i.run(command)
+	if err != nil {
+		return false, &errors.CommandError{Cmd: command, Output: output, Err: err}
+	}
+
+	if !i.succeeded("SetPowerRestorePolicy", output, "successfully") {
+		return status, &errors.CommandError{Cmd: command, Output: output, Err: err}
+	}
+
+	err = i.scheduleBiosJob()
+	if err != nil {
+		return false, err
+	}
+
+	return true, err
+}
+
+// SetBootMode defines the bios boot mode, the change is applied by a job that runs on the next reboot
+// so pendingReboot is returned true. Switching the boot mode resets the boot order, it needs to be
+// applied again afterwards
+func (i *IDrac8) SetBootMode(mode devices.BootMode) (pendingReboot bool, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "SetBootMode", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	value, ok := biosBootModes[mode]
+	if !ok {
+		return pendingReboot, fmt.Errorf("unknown boot mode: %s", mode)
+	}
+
+	err = i.sshLoginRW()
+	if err != nil {
+		return pendingReboot, err
+	}
+
+	command := fmt.Sprintf("racadm set BIOS.BiosBootSettings.BootMode %s", value)
+	output, err := i.run(command)
+	if err != nil {
+		return false, &errors.CommandError{Cmd: command, Output: output, Err: err}
+	}
+
+	if !i.succeeded("SetBootMode", output, "successfully") {
+		return pendingReboot, &errors.CommandError{Cmd: command, Output: output, Err: err}
+	}
+
+	err = i.scheduleBiosJob()
+	if err != nil {
+		return false, err
+	}
+
+	log.WithFields(log.Fields{"step": helper.WhosCalling(), "IP": i.ip, "Model": i.BmcType(), "BootMode": mode}).Warn("Boot mode changed, the boot order will be reset on the next reboot.")
+
+	return true, err
+}
+
+// SOL opens the serial over lan console of the machine using ipmi, it's closed when ctx is done.
+// The console output is copied to record when given. A console in use by another session is
+// reported as *errors.SOLBusyError, force disconnects that session instead
+func (i *IDrac8) SOL(ctx context.Context, record io.Writer, force bool) (console io.ReadWriteCloser, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "SOL", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	im, err := ipmi.New(i.username, i.password, i.ip)
+	if err != nil {
+		return console, err
+	}
+
+	return im.SOL(ctx, record, force)
+}
+
+// consoleEscape is the ^\ sequence detaching from racadm console
+var consoleEscape = []byte{0x1c}
+
+// ConsoleStream opens the serial console of the machine over ssh with racadm console com2, unlike
+// SOL it doesn't require ipmi over lan. The console is detached when it's closed or ctx is done,
+// an orphaned console would otherwise keep the next sessions from opening it
+func (i *IDrac8) ConsoleStream(ctx context.Context) (console io.ReadWriteCloser, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "ConsoleStream", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	if i.runner != nil || i.dryRun {
+		return console, fmt.Errorf("the console requires a ssh session, it can't go through a Runner nor run dry")
+	}
+
+	err = i.sshLoginContext(ctx)
+	if err != nil {
+		return console, err
+	}
+
+	return i.sshClient.Console(ctx, "racadm console com2", consoleEscape)
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 
 	"github.com/bmc-toolbox/bmclib/cfgresources"
 	"github.com/bmc-toolbox/bmclib/internal/helper"
@@ -105,6 +106,307 @@ func (i *IDrac8) ApplyCfg(config *cfgresources.ResourcesConfig) (err error) {
 	return err
 }
 
+// CreateUserInSlot creates or updates the user account held in the given slot, slots range from 2 to 16.
+// A slot holding a different account is only overwritten when replace is set
+func (i *IDrac8) CreateUserInSlot(index int, username string, password string, role string, replace bool) (err error) {
+	if index < 2 || index > userSlots {
+		return fmt.Errorf("user slot %d out of range, expected 2 to %d", index, userSlots)
+	}
+
+	if username == "" || password == "" {
+		return errors.New("username and password are required")
+	}
+
+	if !isRoleValid(role) {
+		return errors.New("role expected to be one of 'admin', 'user'")
+	}
+
+	err = i.httpLogin()
+	if err != nil {
+		return err
+	}
+
+	idracUsers, err := i.queryUsers()
+	if err != nil {
+		return err
+	}
+
+	if userID, _, exists := userInIdrac(username, idracUsers); exists && userID != index {
+		return fmt.Errorf("user %s already exists in slot %d", username, userID)
+	}
+
+	user := idracUsers[index]
+	if user.UserName != "" && user.UserName != username && !replace {
+		return fmt.Errorf("user slot %d is already used by %s", index, user.UserName)
+	}
+
+	user.UserName = username
+	user.Password = password
+	user.Enable = "Enabled"
+	user.SolEnable = "Enabled"
+	user.Privilege, user.IpmiLanPrivilege = rolePrivileges(role)
+
+	return i.putUser(index, user)
+}
+
+// DiffCfg compares the given configuration with the one present in the bmc without changing it,
+// an entry is returned for every setting ApplyCfg would modify. The settings are read back from
+// the same config groups and data parameters ApplyCfg writes, License and Ssl aren't applied by
+// ApplyCfg and are left out.
+func (i *IDrac8) DiffCfg(config *cfgresources.ResourcesConfig) (diff []cfgresources.ConfigDiff, err error) {
+	err = i.httpLogin()
+	if err != nil {
+		return diff, err
+	}
+
+	if config.User != nil {
+		diff, err = i.diffUserParams(diff, config.User)
+		if err != nil {
+			return diff, err
+		}
+	}
+
+	if config.Syslog != nil {
+		diff, err = i.diffSyslogParams(diff, config.Syslog)
+		if err != nil {
+			return diff, err
+		}
+	}
+
+	if config.Network != nil {
+		diff, err = i.diffNetworkParams(diff, config.Network)
+		if err != nil {
+			return diff, err
+		}
+	}
+
+	if config.Ntp != nil {
+		diff, err = i.diffNtpParams(diff, config.Ntp)
+		if err != nil {
+			return diff, err
+		}
+	}
+
+	if config.Ldap != nil {
+		diff, err = i.diffLdapParams(diff, config.Ldap)
+		if err != nil {
+			return diff, err
+		}
+	}
+
+	if config.LdapGroup != nil {
+		diff, err = i.diffLdapGroupParams(diff, config.LdapGroup, config.Ldap)
+		if err != nil {
+			return diff, err
+		}
+	}
+
+	return diff, err
+}
+
+// appendDiff records field in diff when its current value isn't the desired one
+func appendDiff(diff []cfgresources.ConfigDiff, field string, current string, desired string) []cfgresources.ConfigDiff {
+	if current == desired {
+		return diff
+	}
+
+	return append(diff, cfgresources.ConfigDiff{Field: field, Current: current, Desired: desired})
+}
+
+// boolParam returns the value a data parameter holds for a switch
+func boolParam(enable bool) string {
+	if enable {
+		return "1"
+	}
+
+	return "0"
+}
+
+func (i *IDrac8) diffUserParams(diff []cfgresources.ConfigDiff, cfgUsers []*cfgresources.User) ([]cfgresources.ConfigDiff, error) {
+	idracUsers, err := i.queryUsers()
+	if err != nil {
+		return diff, err
+	}
+
+	for _, cfgUser := range cfgUsers {
+		_, userInfo, uExists := userInIdrac(cfgUser.Name, idracUsers)
+
+		// disabled users that aren't present won't be touched by ApplyCfg
+		if !cfgUser.Enable && !uExists {
+			continue
+		}
+
+		current := "Absent"
+		if uExists {
+			current = strings.Title(strings.ToLower(userInfo.Enable))
+		}
+
+		desired := "Disabled"
+		if cfgUser.Enable {
+			desired = "Enabled"
+		}
+
+		diff = appendDiff(diff, fmt.Sprintf("User.%s.Enable", cfgUser.Name), current, desired)
+
+		if !cfgUser.Enable {
+			continue
+		}
+
+		role := "user"
+		if cfgUser.Role == "admin" {
+			role = "admin"
+		}
+
+		var currentRole string
+		switch userInfo.Privilege {
+		case "511":
+			currentRole = "admin"
+		case "499":
+			currentRole = "user"
+		default:
+			currentRole = userInfo.Privilege
+		}
+
+		diff = appendDiff(diff, fmt.Sprintf("User.%s.Role", cfgUser.Name), currentRole, role)
+	}
+
+	return diff, err
+}
+
+func (i *IDrac8) diffSyslogParams(diff []cfgresources.ConfigDiff, cfg *cfgresources.Syslog) ([]cfgresources.ConfigDiff, error) {
+	// applySyslogParams skips a syslog without server
+	if cfg.Server == "" {
+		return diff, nil
+	}
+
+	syslog := Syslog{}
+	err := i.queryConfigGroup(syslogGroup, &syslog)
+	if err != nil {
+		return diff, err
+	}
+
+	port := 514
+	if cfg.Port != 0 {
+		port = cfg.Port
+	}
+
+	enable := "Disabled"
+	if cfg.Enable {
+		enable = "Enabled"
+	}
+
+	diff = appendDiff(diff, "Syslog.Server", syslog.Server1, cfg.Server)
+	diff = appendDiff(diff, "Syslog.Port", syslog.Port, strconv.Itoa(port))
+	diff = appendDiff(diff, "Syslog.Enable", syslog.Enable, enable)
+
+	return diff, err
+}
+
+func (i *IDrac8) diffNetworkParams(diff []cfgresources.ConfigDiff, cfg *cfgresources.Network) ([]cfgresources.ConfigDiff, error) {
+	params, err := i.queryData("dhcpForDNSDomain", "ipmiLAN", "serialOverLanEnabled", "racRedirectEna")
+	if err != nil {
+		return diff, err
+	}
+
+	diff = appendDiff(diff, "Network.DNSFromDHCP", params["dhcpForDNSDomain"], boolParam(cfg.DNSFromDHCP))
+	diff = appendDiff(diff, "Network.IpmiEnable", params["ipmiLAN"], boolParam(cfg.IpmiEnable))
+	diff = appendDiff(diff, "Network.SolEnable", params["serialOverLanEnabled"], boolParam(cfg.SolEnable))
+	diff = appendDiff(diff, "Network.SerialRedirection", params["racRedirectEna"], boolParam(cfg.SolEnable))
+
+	return diff, err
+}
+
+func (i *IDrac8) diffNtpParams(diff []cfgresources.ConfigDiff, cfg *cfgresources.Ntp) ([]cfgresources.ConfigDiff, error) {
+	// applyNtpParams skips an ntp config without server1 or timezone
+	if cfg.Server1 == "" || cfg.Timezone == "" {
+		return diff, nil
+	}
+
+	params, err := i.queryData("tm_tz_str_zone", "tm_ntp_int_opmode", "tm_ntp_str_server1", "tm_ntp_str_server2", "tm_ntp_str_server3")
+	if err != nil {
+		return diff, err
+	}
+
+	diff = appendDiff(diff, "Ntp.Timezone", params["tm_tz_str_zone"], cfg.Timezone)
+	diff = appendDiff(diff, "Ntp.Enable", params["tm_ntp_int_opmode"], boolParam(cfg.Enable))
+	diff = appendDiff(diff, "Ntp.Server1", params["tm_ntp_str_server1"], cfg.Server1)
+	diff = appendDiff(diff, "Ntp.Server2", params["tm_ntp_str_server2"], cfg.Server2)
+	diff = appendDiff(diff, "Ntp.Server3", params["tm_ntp_str_server3"], cfg.Server3)
+
+	return diff, err
+}
+
+func (i *IDrac8) diffLdapParams(diff []cfgresources.ConfigDiff, cfg *cfgresources.Ldap) ([]cfgresources.ConfigDiff, error) {
+	// applyLdapParams leaves the ldap untouched without a server
+	if cfg.Server == "" {
+		return diff, nil
+	}
+
+	params, err := i.queryData("xGLServer", "xGLSearchFilter")
+	if err != nil {
+		return diff, err
+	}
+
+	diff = appendDiff(diff, "Ldap.Server", params["xGLServer"], cfg.Server)
+	if cfg.SearchFilter != "" {
+		diff = appendDiff(diff, "Ldap.SearchFilter", params["xGLSearchFilter"], cfg.SearchFilter)
+	}
+
+	return diff, err
+}
+
+func (i *IDrac8) diffLdapGroupParams(diff []cfgresources.ConfigDiff, cfgGroup []*cfgresources.LdapGroup, cfgLdap *cfgresources.Ldap) ([]cfgresources.ConfigDiff, error) {
+	if cfgLdap == nil {
+		return diff, errors.New("the LdapGroup section can't be compared without the Ldap section")
+	}
+
+	params := []string{"LDAPEnableMode", "xGLBaseDN", "xGLUserLogin", "xGLGroupMem", "xGLBindDN", "xGLServerPort"}
+	for groupId := 1; groupId <= 5; groupId++ {
+		params = append(params, fmt.Sprintf("xGLGroup%dName", groupId), fmt.Sprintf("xGLGroup%dPriv", groupId))
+	}
+
+	current, err := i.queryData(params...)
+	if err != nil {
+		return diff, err
+	}
+
+	// the groups are laid out in slots the way applyLdapGroupParams does
+	groupId := 1
+	for _, group := range cfgGroup {
+		if !group.Enable || group.Role == "" {
+			continue
+		}
+
+		if group.Group == "" || group.GroupBaseDn == "" || !i.isRoleValid(group.Role) {
+			return diff, fmt.Errorf("ldap group %s can't be compared, it expects a Group, a GroupBaseDn and a role admin OR user", group.Group)
+		}
+
+		privId := "497"
+		if group.Role == "admin" {
+			privId = "511"
+		}
+
+		name := fmt.Sprintf("xGLGroup%dName", groupId)
+		priv := fmt.Sprintf("xGLGroup%dPriv", groupId)
+		diff = appendDiff(diff, fmt.Sprintf("LdapGroup.%d.Group", groupId), current[name], fmt.Sprintf("%s,%s", group.Group, group.GroupBaseDn))
+		diff = appendDiff(diff, fmt.Sprintf("LdapGroup.%d.Privilege", groupId), current[priv], privId)
+		groupId++
+	}
+
+	for slot := groupId + 1; slot <= 5; slot++ {
+		diff = appendDiff(diff, fmt.Sprintf("LdapGroup.%d.Privilege", slot), current[fmt.Sprintf("xGLGroup%dPriv", slot)], "0")
+	}
+
+	diff = appendDiff(diff, "Ldap.EnableMode", current["LDAPEnableMode"], "3")
+	diff = appendDiff(diff, "Ldap.BaseDn", current["xGLBaseDN"], cfgLdap.BaseDn)
+	diff = appendDiff(diff, "Ldap.UserAttribute", current["xGLUserLogin"], cfgLdap.UserAttribute)
+	diff = appendDiff(diff, "Ldap.GroupAttribute", current["xGLGroupMem"], cfgLdap.GroupAttribute)
+	diff = appendDiff(diff, "Ldap.BindDn", current["xGLBindDN"], cfgLdap.BindDn)
+	diff = appendDiff(diff, "Ldap.Port", current["xGLServerPort"], strconv.Itoa(cfgLdap.Port))
+
+	return diff, err
+}
+
 // Encodes the string the way idrac expects credentials to be sent
 // foobar == @066@06f@06f@062@061@072
 // convert ever character to its hex equiv, and prepend @0
@@ -202,13 +504,7 @@ func (i *IDrac8) applyUserParams(cfgUsers []*cfgresources.User) (err error) {
 			userInfo.Password = cfgUser.Password
 
 			//set appropriate privileges
-			if cfgUser.Role == "admin" {
-				userInfo.Privilege = "511"
-				userInfo.IpmiLanPrivilege = "Administrator"
-			} else {
-				userInfo.Privilege = "499"
-				userInfo.IpmiLanPrivilege = "Operator"
-			}
+			userInfo.Privilege, userInfo.IpmiLanPrivilege = rolePrivileges(cfgUser.Role)
 
 			err = i.putUser(userId, userInfo)
 			if err != nil {
@@ -288,7 +584,7 @@ func (i *IDrac8) applySyslogParams(cfg *cfgresources.Syslog) (err error) {
 
 	data := make(map[string]Syslog)
 
-	data["iDRAC.SysLog"] = Syslog{
+	data[syslogGroup] = Syslog{
 		Port:    strconv.Itoa(port),
 		Server1: cfg.Server,
 		Server2: "",
@@ -304,7 +600,7 @@ func (i *IDrac8) applySyslogParams(cfg *cfgresources.Syslog) (err error) {
 		return err
 	}
 
-	endpoint := "sysmgmt/2012/server/configgroup/iDRAC.SysLog"
+	endpoint := fmt.Sprintf("sysmgmt/2012/server/configgroup/%s", syslogGroup)
 	response, _, err := i.put(endpoint, payload)
 	if err != nil {
 		log.WithFields(log.Fields{
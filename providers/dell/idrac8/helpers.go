@@ -1,13 +1,560 @@
 package idrac8
 
 import (
+	"bufio"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/bmc-toolbox/bmclib/cfgresources"
+	"github.com/bmc-toolbox/bmclib/devices"
+	"github.com/bmc-toolbox/bmclib/internal/redact"
+	"github.com/bmc-toolbox/bmclib/internal/sshclient"
+	"github.com/bmc-toolbox/bmclib/providers/dell"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
 )
 
+// commands known to ask "Are you sure? (y/n)" before doing anything, depending on the
+// firmware the power and reset actions may prompt as well
+var confirmationPrompts = []string{
+	"racadm racresetcfg",
+	"racadm racreset",
+	"racadm jobqueue delete",
+	"racadm serveraction",
+}
+
+// Runner runs a command on the bmc and returns what it printed, the ssh client is the
+// one used unless another one is set with SetRunner, e.g. a fake answering the tests
+type Runner interface {
+	Run(command string) (output string, err error)
+}
+
+// commandRunner returns the Runner the commands are sent to
+func (i *IDrac8) commandRunner() Runner {
+	if i.dryRun {
+		return dryRunner{ip: i.ip}
+	}
+
+	if i.runner != nil {
+		return i.runner
+	}
+
+	return i.sshClient
+}
+
+// dryRunner logs the commands instead of sending them to the bmc and answers each one with
+// the command itself, see SetDryRun
+type dryRunner struct {
+	ip string
+}
+
+// Run logs the command, nothing is sent to the bmc
+func (r dryRunner) Run(command string) (output string, err error) {
+	log.WithFields(log.Fields{"step": "dry run", "vendor": dell.VendorID, "ip": r.ip, "command": redact.String(command)}).Info("command not sent to the bmc")
+	return command, err
+}
+
+// run executes the command over ssh, answering the confirmation prompt of the commands that print one
+func (i *IDrac8) run(command string) (output string, err error) {
+	return i.runContext(context.Background(), command)
+}
+
+// runContext works as run aborting the command when ctx is done
+func (i *IDrac8) runContext(ctx context.Context, command string) (output string, err error) {
+	if i.runner != nil || i.dryRun {
+		return i.commandRunner().Run(command)
+	}
+
+	for _, prompt := range confirmationPrompts {
+		if strings.HasPrefix(command, prompt) {
+			return i.sshClient.RunWithConfirmationContext(ctx, command)
+		}
+	}
+
+	return i.sshClient.RunContext(ctx, command)
+}
+
+// stream runs the command over ssh returning its output as it's printed, a Runner set with
+// SetRunner prints it all at once
+func (i *IDrac8) stream(command string) (stream io.ReadCloser, err error) {
+	if i.runner != nil || i.dryRun {
+		output, err := i.commandRunner().Run(command)
+		if err != nil {
+			return stream, err
+		}
+
+		return ioutil.NopCloser(strings.NewReader(output)), err
+	}
+
+	return i.sshClient.Stream(command)
+}
+
+// sessionDropped tells if the command failed because the bmc closed the ssh session under it,
+// as it does when its ip address changes. A session that couldn't be opened never got the command
+func sessionDropped(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if _, notSent := err.(*sshclient.SessionError); notSent {
+		return false
+	}
+
+	if _, missing := err.(*ssh.ExitMissingError); missing {
+		return true
+	}
+
+	reason := strings.ToLower(err.Error())
+	for _, dropped := range []string{"eof", "connection reset", "broken pipe"} {
+		if strings.Contains(reason, dropped) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateNetwork checks the ipv4 settings before they are sent to the bmc, the
+// netmask has to be contiguous and the gateway reachable within it
+func validateNetwork(ip string, netmask string, gateway string) (err error) {
+	address := net.ParseIP(ip).To4()
+	if address == nil {
+		return fmt.Errorf("invalid ipv4 address: %q", ip)
+	}
+
+	mask := net.ParseIP(netmask).To4()
+	if mask == nil {
+		return fmt.Errorf("invalid netmask: %q", netmask)
+	}
+	if ones, _ := net.IPMask(mask).Size(); ones == 0 {
+		return fmt.Errorf("invalid netmask: %q", netmask)
+	}
+
+	router := net.ParseIP(gateway).To4()
+	if router == nil {
+		return fmt.Errorf("invalid gateway: %q", gateway)
+	}
+
+	subnet := &net.IPNet{IP: address.Mask(net.IPMask(mask)), Mask: net.IPMask(mask)}
+	if !subnet.Contains(router) {
+		return fmt.Errorf("gateway %s isn't within %s", gateway, subnet)
+	}
+
+	return err
+}
+
+// hostLabel is a label of a dns name, e.g. ntp0 in ntp0.example.com
+var hostLabel = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+
+// validHost tells if host is an ip address or a dns name the bmc can resolve
+func validHost(host string) bool {
+	if net.ParseIP(host) != nil {
+		return true
+	}
+
+	if host == "" || len(host) > 253 {
+		return false
+	}
+
+	for _, label := range strings.Split(strings.TrimSuffix(host, "."), ".") {
+		if !hostLabel.MatchString(label) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// transientFailures are the answers of the commands the bmc refused because it was busy,
+// running them again a bit later usually works
+var transientFailures = []string{
+	"rac busy",
+	"is busy",
+	"try again",
+	"temporarily unavailable",
+}
+
+// connectionFailures are the errors of a connection that dropped or timed out, they're only
+// transient while connecting as the bmc may have run a command it lost the session of
+var connectionFailures = []string{
+	"connection reset",
+	"broken pipe",
+	"timed out",
+	"eof",
+}
+
+// clockSkewFailures are the login failures a skewed bmc clock causes, the credentials are
+// rejected or the certificates look expired or not yet valid
+var clockSkewFailures = []string{
+	"unable to authenticate",
+	"cert has expired",
+	"cert is not yet valid",
+	"certificate has expired or is not yet valid",
+}
+
+// defaultMaxClockSkew is the clock difference past which a rejected login is put on the bmc clock
+// when the clock correction isn't enabled, it's the tolerance kerberos allows by default
+const defaultMaxClockSkew = 5 * time.Minute
+
+// clockSkewFailure tells if the login failed in a way a skewed bmc clock explains
+func clockSkewFailure(err error) bool {
+	answer := strings.ToLower(err.Error())
+	for _, failure := range clockSkewFailures {
+		if strings.Contains(answer, failure) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// transient tells if the command failed for a reason that goes away by itself, the failures
+// that would happen again, e.g. an authentication failure or an unknown command, aren't.
+// A connection failure is transient only when the command wasn't sent
+func transient(output string, err error, sent bool) bool {
+	if _, notSent := err.(*sshclient.SessionError); notSent {
+		return true
+	}
+
+	answer := strings.ToLower(output)
+	failures := transientFailures
+	if !sent {
+		failures = append(append([]string{}, transientFailures...), connectionFailures...)
+		if err != nil {
+			answer = fmt.Sprintf("%s %s", answer, strings.ToLower(err.Error()))
+		}
+	}
+
+	for _, failure := range failures {
+		if strings.Contains(answer, failure) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RunWithRetry runs the command over ssh up to the given attempts, waiting for backoff before the
+// first retry and more as they go on. Only the transient failures are retried, a connection lost
+// once the command was sent isn't as the bmc may have run it. The error of the last attempt is
+// returned when all of them fail
+func (i *IDrac8) RunWithRetry(command string, attempts int, backoff time.Duration) (output string, err error) {
+	if attempts < 1 {
+		return output, fmt.Errorf("expected at least one attempt, found %d", attempts)
+	}
+
+	if backoff < 0 {
+		return output, fmt.Errorf("invalid backoff: %s", backoff)
+	}
+
+	delay := backoff
+	for attempt := 1; ; attempt++ {
+		sent := false
+		err = i.sshLogin()
+		if err == nil {
+			sent = true
+			output, err = i.run(command)
+			if err == nil {
+				return output, err
+			}
+		}
+
+		if attempt >= attempts || !transient(output, err, sent) {
+			return output, err
+		}
+
+		log.WithFields(log.Fields{"step": "RunWithRetry", "vendor": dell.VendorID, "ip": i.ip, "command": command, "error": err}).Debug("transient failure, retrying")
+		time.Sleep(delay)
+		if delay < 4*backoff {
+			delay = delay * 3 / 2
+		}
+	}
+}
+
+// racadmValue returns the value printed by racadm get for a single attribute
+func racadmValue(output string) (value string) {
+	for _, line := range strings.Split(output, "\n") {
+		data := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(data) == 2 && !strings.HasPrefix(data[0], "[") {
+			value = strings.TrimSpace(data[1])
+		}
+	}
+
+	return value
+}
+
+// racTimeFormat is the format used by racadm getractime -d and setractime -d, followed by the utc offset in minutes
+const racTimeFormat = "20060102150405.000000"
+
+// parsePowerStatus normalizes the state printed by racadm serveraction powerstatus
+func parsePowerStatus(output string) (state string) {
+	status := strings.TrimSpace(output)
+	if idx := strings.Index(status, "Server power status:"); idx != -1 {
+		status = strings.TrimSpace(status[idx+len("Server power status:"):])
+	}
+
+	switch strings.ToUpper(strings.Replace(status, " ", "", -1)) {
+	case "ON":
+		return devices.PowerStateOn
+	case "OFF":
+		return devices.PowerStateOff
+	case "POWERINGON":
+		return devices.PowerStatePoweringOn
+	case "POWERINGOFF":
+		return devices.PowerStatePoweringOff
+	}
+
+	return devices.PowerStateUnknown
+}
+
+// parseRacTime parses the time printed by racadm getractime -d, e.g. 20181014153000.000000+060
+func parseRacTime(output string) (t time.Time, err error) {
+	output = strings.TrimSpace(output)
+	if len(output) < len(racTimeFormat)+2 {
+		return t, fmt.Errorf("invalid bmc time: %q", output)
+	}
+
+	offset, err := strconv.Atoi(output[len(racTimeFormat):])
+	if err != nil {
+		return t, fmt.Errorf("invalid bmc time offset: %q", output)
+	}
+
+	return time.ParseInLocation(racTimeFormat, output[:len(racTimeFormat)], time.FixedZone("", offset*60))
+}
+
+// biosRestorePolicies maps the power restore policies to the values of BIOS.SysSecurity.AcPwrRcvry
+var biosRestorePolicies = map[devices.PowerRestorePolicy]string{
+	devices.PowerRestoreAlwaysOn:  "On",
+	devices.PowerRestoreAlwaysOff: "Off",
+	devices.PowerRestoreLast:      "Last",
+}
+
+// bootDevices maps the devices accepted by SetBootDevice to the values of cfgServerFirstBootDevice
+var bootDevices = map[string]string{
+	"pxe":   "PXE",
+	"disk":  "HDD",
+	"bios":  "BIOS",
+	"cdrom": "CD-DVD",
+}
+
+// remoteImageShare converts the url of an iso image to the share path expected by racadm remoteimage,
+// http(s)://host/path stays as is, nfs://host/path becomes host:/path and cifs://host/path //host/path
+func remoteImageShare(isoURL string) (share string, username string, password string, err error) {
+	u, err := url.Parse(isoURL)
+	if err != nil {
+		return share, username, password, fmt.Errorf("invalid iso url %q: %s", isoURL, err)
+	}
+
+	if u.Host == "" || u.Path == "" || u.Path == "/" {
+		return share, username, password, fmt.Errorf("invalid iso url %q: the host and the image path are required", isoURL)
+	}
+
+	if u.User != nil {
+		username = u.User.Username()
+		password, _ = u.User.Password()
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		u.User = nil
+		return u.String(), username, password, err
+	case "nfs":
+		return fmt.Sprintf("%s:%s", u.Host, u.Path), username, password, err
+	case "cifs":
+		return fmt.Sprintf("//%s%s", u.Host, u.Path), username, password, err
+	}
+
+	return share, username, password, fmt.Errorf("unsupported iso url scheme %q, expected one of http, https, nfs or cifs", u.Scheme)
+}
+
+// firmwarePackages are the extensions of the packages racadm update flashes, the Dell update packages
+// and the idrac firmware images
+var firmwarePackages = []string{".exe", ".d9"}
+
+// jobIDPattern matches the job ids printed by racadm, e.g. Commit JID = JID_372366001531
+var jobIDPattern = regexp.MustCompile(`JID_[0-9]+`)
+
+// firmwareSource splits the http(s) url of a firmware package into the arguments of racadm update,
+// the package file, its location as the host followed by the directory and the transport
+func firmwareSource(firmwareURL string) (file string, location string, transport string, err error) {
+	u, err := url.Parse(firmwareURL)
+	if err != nil {
+		return file, location, transport, fmt.Errorf("invalid firmware url %q: %s", firmwareURL, err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return file, location, transport, fmt.Errorf("unsupported firmware url scheme %q, expected one of http or https", u.Scheme)
+	}
+
+	if u.Host == "" || u.User != nil || u.RawQuery != "" {
+		return file, location, transport, fmt.Errorf("invalid firmware url %q: expected http(s)://host/path/package", firmwareURL)
+	}
+
+	name := path.Base(u.Path)
+	valid := false
+	for _, extension := range firmwarePackages {
+		if strings.HasSuffix(strings.ToLower(name), extension) && len(name) > len(extension) {
+			valid = true
+		}
+	}
+	if !valid {
+		return file, location, transport, fmt.Errorf("invalid firmware url %q: expected a .exe or .d9 package", firmwareURL)
+	}
+
+	location = strings.TrimSuffix(u.Host+path.Dir(u.Path), "/")
+	return name, location, strings.ToUpper(u.Scheme), err
+}
+
+// succeeded tells if the output of the given action reports success, using the matcher
+// defined with SetSuccessMatcher or looking for the expected wording otherwise
+func (i *IDrac8) succeeded(action string, output string, expected string) bool {
+	if i.dryRun {
+		return true
+	}
+
+	if matcher, ok := i.successMatchers[action]; ok {
+		return matcher(output)
+	}
+
+	return strings.Contains(output, expected)
+}
+
+// biosBootModes maps the boot modes to the values of BIOS.BiosBootSettings.BootMode
+var biosBootModes = map[devices.BootMode]string{
+	devices.BootModeUEFI:   "Uefi",
+	devices.BootModeLegacy: "Bios",
+}
+
+// scheduleBiosJob creates the job committing the pending bios changes on the next reboot, its
+// answer is matched as the BiosJob action
+func (i *IDrac8) scheduleBiosJob() (err error) {
+	output, err := i.run("racadm jobqueue create BIOS.Setup.1-1")
+	if err != nil || !i.succeeded("BiosJob", output, "Successfully scheduled a job") {
+		return fmt.Errorf("unable to schedule the bios job: %s", output)
+	}
+
+	return err
+}
+
+// parseRacadmFields parses the key=value lines printed by racadm jobqueue view -i or getniccfg,
+// brackets removed. When a key is repeated the first value is kept
+//
+// [Job ID=JID_372366001531]
+// Job Name=Firmware Update: iDRAC
+// Status=Downloading
+// Percent Complete=[20]
+func parseRacadmFields(output string) (fields map[string]string) {
+	fields = make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.Trim(strings.TrimSpace(line), "[]")
+		data := strings.SplitN(line, "=", 2)
+		if len(data) != 2 {
+			continue
+		}
+
+		key := strings.TrimSpace(data[0])
+		if _, ok := fields[key]; ok {
+			continue
+		}
+		fields[key] = strings.Trim(strings.TrimSpace(data[1]), "[]")
+	}
+
+	return fields
+}
+
+// jobNotFound tells if racadm jobqueue view -i rejected the job id as missing from the job queue
+func jobNotFound(output string) bool {
+	answer := strings.ToLower(output)
+	return strings.Contains(answer, "invalid") || strings.Contains(answer, "not found") || strings.Contains(answer, "does not exist")
+}
+
+// parseHwInventory splits racadm hwinventory in the fields of each component, in the order they're
+// listed. The InstanceID of the component, e.g. PSU.Slot.1, is kept under the InstanceID key and
+// when a key is repeated the first value is kept
+func parseHwInventory(output string) (components []map[string]string) {
+	var component map[string]string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[InstanceID:") {
+			id := strings.TrimSuffix(strings.TrimPrefix(line, "[InstanceID:"), "]")
+			component = map[string]string{"InstanceID": strings.TrimSpace(id)}
+			components = append(components, component)
+			continue
+		}
+
+		data := strings.SplitN(line, "=", 2)
+		if component == nil || len(data) != 2 {
+			continue
+		}
+
+		key := strings.TrimSpace(data[0])
+		if _, ok := component[key]; !ok {
+			component[key] = strings.TrimSpace(data[1])
+		}
+	}
+
+	return components
+}
+
+// hwQuantity reads the number of the hwinventory values followed by their unit, e.g. 750 W
+func hwQuantity(value string) (quantity float64, err error) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return quantity, fmt.Errorf("unexpected quantity: %q", value)
+	}
+
+	return strconv.ParseFloat(fields[0], 64)
+}
+
+// parseBootOrder returns the boot sequence of racadm get BIOS.BiosBootSettings for the boot mode
+// in use, the bios mode boots from BiosBootSeq and the uefi mode from UefiBootSeq. Both are comma
+// separated lists, a change not applied yet is printed after them as "(Pending Value=...)"
+func parseBootOrder(output string) (order []string, err error) {
+	fields := parseRacadmFields(output)
+
+	var sequence string
+	mode := strings.Fields(fields["BootMode"])
+	switch {
+	case len(mode) == 0:
+		return order, fmt.Errorf("unable to find the boot mode: %s", output)
+	case strings.EqualFold(mode[0], biosBootModes[devices.BootModeLegacy]):
+		sequence = fields["BiosBootSeq"]
+	case strings.EqualFold(mode[0], biosBootModes[devices.BootModeUEFI]):
+		sequence = fields["UefiBootSeq"]
+	default:
+		return order, fmt.Errorf("unknown boot mode: %s", mode[0])
+	}
+
+	if idx := strings.Index(sequence, "(Pending Value="); idx != -1 {
+		sequence = sequence[:idx]
+	}
+
+	for _, device := range strings.Split(sequence, ",") {
+		device = strings.TrimSpace(device)
+		if device != "" {
+			order = append(order, device)
+		}
+	}
+
+	if len(order) == 0 {
+		return order, fmt.Errorf("unable to find the boot sequence of the %s boot mode: %s", mode[0], output)
+	}
+
+	return order, err
+}
+
 // Return bool value if the role is valid.
 func isRoleValid(role string) bool {
 
@@ -21,6 +568,12 @@ func isRoleValid(role string) bool {
 	return false
 }
 
+// isPasswordValid tells if the password can be passed as a racadm argument,
+// racadm splits it on spaces and the quotes aren't escaped
+func isPasswordValid(password string) bool {
+	return password != "" && !strings.ContainsAny(password, " \t\"'")
+}
+
 // Return bool value if the role is valid.
 func (i *IDrac8) validateUserCfg(cfgUsers []*cfgresources.User) (err error) {
 
@@ -44,6 +597,60 @@ func (i *IDrac8) validateUserCfg(cfgUsers []*cfgresources.User) (err error) {
 	return nil
 }
 
+// pngSignature starts every png image, the web interface answers with an html page when there's no image
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// syslogGroup is the config group holding the remote syslog settings
+const syslogGroup = "iDRAC.SysLog"
+
+// userSlots is the highest user slot, slot 1 holds the anonymous user and can't be used
+const userSlots = 16
+
+// dryRunUserSlot stands for the user slot the commands are logged with in dry run,
+// the slots can't be looked up without reaching the bmc
+const dryRunUserSlot = 2
+
+// parseUserAdmin returns the user name held by each user slot listed by racadm getconfig -g cfgUserAdmin,
+// the free slots are listed with an empty user name
+//
+// # cfgUserAdminIndex=2
+// cfgUserAdminUserName=root
+// # cfgUserAdminPassword=******** (Write-Only)
+// cfgUserAdminEnable=1
+func parseUserAdmin(output string) (users map[int]string) {
+	users = make(map[int]string)
+	index := 0
+	for _, line := range strings.Split(output, "\n") {
+		data := strings.SplitN(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "#")), "=", 2)
+		if len(data) != 2 {
+			continue
+		}
+
+		switch strings.TrimSpace(data[0]) {
+		case "cfgUserAdminIndex":
+			index, _ = strconv.Atoi(strings.TrimSpace(data[1]))
+			if index > 0 {
+				users[index] = ""
+			}
+		case "cfgUserAdminUserName":
+			if index > 0 {
+				users[index] = strings.TrimSpace(data[1])
+			}
+		}
+	}
+
+	return users
+}
+
+// rolePrivileges returns the privilege bitmask and ipmi lan privilege granted to the given role
+func rolePrivileges(role string) (privilege string, ipmiLanPrivilege string) {
+	if role == "admin" {
+		return "511", "Administrator"
+	}
+
+	return "499", "Operator"
+}
+
 // IDrac8 supports upto 16 users, user 0 is reserved
 // this function returns an empty user slot that can be used for a new user account
 func getEmptyUserSlot(idracUsers UserInfo) (userId int, user User, err error) {
@@ -94,3 +701,160 @@ func (i *IDrac8) putUser(userId int, user User) (err error) {
 
 	return err
 }
+
+// selTimeFormats are the layouts used by racadm to print the SEL timestamps, newer
+// firmwares print the weekday and month names instead of the numeric date
+var selTimeFormats = []string{"01/02/2006 15:04:05", "Mon Jan 2 2006 15:04:05"}
+
+// selSeverities ranks the severities printed by racadm getsel
+var selSeverities = map[string]int{
+	"ok":           0,
+	"non-critical": 1,
+	"critical":     2,
+}
+
+// matchSEL tells if the given SEL record passes the filter
+func matchSEL(filter SELFilter, entry SELEntry) bool {
+	if filter.MinSeverity != "" && selSeverities[strings.ToLower(entry.Severity)] < selSeverities[strings.ToLower(filter.MinSeverity)] {
+		return false
+	}
+
+	if !filter.Since.IsZero() && entry.Timestamp.Before(filter.Since) {
+		return false
+	}
+
+	if !filter.Until.IsZero() && entry.Timestamp.After(filter.Until) {
+		return false
+	}
+
+	if filter.Sensor != "" {
+		sensor := strings.ToLower(filter.Sensor)
+		if !strings.Contains(strings.ToLower(entry.Source), sensor) && !strings.Contains(strings.ToLower(entry.Message), sensor) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseSEL reads the output of racadm getsel record by record,
+// emit is called for every record found and parsing stops as soon as it returns false
+func parseSEL(output io.Reader, emit func(SELEntry) bool) (err error) {
+	var entry *SELEntry
+
+	scanner := bufio.NewScanner(output)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "---") {
+			if entry != nil && !emit(*entry) {
+				return err
+			}
+			entry = nil
+			continue
+		}
+
+		data := strings.SplitN(line, ":", 2)
+		if len(data) != 2 {
+			continue
+		}
+
+		key := strings.TrimSpace(data[0])
+		value := strings.TrimSpace(data[1])
+
+		if key == "Record" {
+			id, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid sel record %q: %v", value, err)
+			}
+			entry = &SELEntry{ID: id}
+			continue
+		}
+
+		if entry == nil {
+			continue
+		}
+
+		switch key {
+		case "Date/Time":
+			entry.Timestamp, err = parseSELTime(value)
+			if err != nil {
+				return err
+			}
+		case "Source":
+			entry.Source = value
+		case "Severity":
+			entry.Severity = value
+		case "Description":
+			entry.Message = value
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return err
+	}
+
+	if entry != nil {
+		emit(*entry)
+	}
+
+	return err
+}
+
+// parseSELTime parses a SEL timestamp in any of the layouts printed by racadm
+func parseSELTime(value string) (t time.Time, err error) {
+	value = strings.Join(strings.Fields(value), " ")
+	for _, layout := range selTimeFormats {
+		t, err = time.Parse(layout, value)
+		if err == nil {
+			return t, err
+		}
+	}
+
+	return t, fmt.Errorf("invalid sel timestamp %q: %v", value, err)
+}
+
+// parseSwInventory parses the output of racadm swinventory, every component is reported
+// once with its installed version and flagged when a different version is staged for it
+func parseSwInventory(output string) (firmware []devices.FirmwareComponent) {
+	installed := make(map[string]*devices.FirmwareComponent)
+	available := make(map[string]string)
+	order := make([]string, 0)
+
+	var name, fqdd string
+	for _, line := range strings.Split(output, "\n") {
+		data := strings.SplitN(line, "=", 2)
+		if len(data) != 2 {
+			continue
+		}
+
+		key := strings.TrimSpace(data[0])
+		value := strings.TrimSpace(data[1])
+
+		switch key {
+		case "ComponentType":
+			name, fqdd = "", ""
+		case "ElementName":
+			name = value
+		case "FQDD":
+			fqdd = value
+		case "Current Version":
+			if _, ok := installed[fqdd]; !ok {
+				order = append(order, fqdd)
+			}
+			installed[fqdd] = &devices.FirmwareComponent{Component: name, Version: value}
+		case "Available Version":
+			available[fqdd] = value
+		}
+	}
+
+	for _, fqdd := range order {
+		component := installed[fqdd]
+		if version, ok := available[fqdd]; ok && version != component.Version {
+			component.UpdateAvailable = true
+		}
+		firmware = append(firmware, *component)
+	}
+
+	return firmware
+}
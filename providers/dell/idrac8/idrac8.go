@@ -2,6 +2,7 @@ package idrac8
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
@@ -11,13 +12,16 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	multierror "github.com/hashicorp/go-multierror"
 
 	"github.com/bmc-toolbox/bmclib/devices"
 	"github.com/bmc-toolbox/bmclib/errors"
+	"github.com/bmc-toolbox/bmclib/internal/helper"
 	"github.com/bmc-toolbox/bmclib/internal/httpclient"
 	"github.com/bmc-toolbox/bmclib/internal/sshclient"
+	"github.com/bmc-toolbox/bmclib/internal/tracing"
 	"github.com/bmc-toolbox/bmclib/providers/dell"
 
 	// this make possible to setup logging and properties at any stage
@@ -32,17 +36,32 @@ const (
 
 // IDrac8 holds the status and properties of a connection to an iDrac device
 type IDrac8 struct {
-	ip             string
-	username       string
-	password       string
-	httpClient     *http.Client
-	sshClient      *sshclient.SSHClient
-	st1            string
-	st2            string
-	serial         string
-	iDracInventory *dell.IDracInventory
+	ip              string
+	username        string
+	password        string
+	httpClient      *http.Client
+	sshClient       *sshclient.SSHClient
+	sshOptions      sshclient.Options
+	runner          Runner
+	dryRun          bool
+	manualLogin     bool
+	traceCtx        context.Context
+	readOnly        bool
+	readOnlyProbed  bool
+	maxClockSkew    time.Duration
+	clockCorrection time.Duration
+	successMatchers map[string]devices.SuccessMatcher
+	st1             string
+	st2             string
+	serial          string
+	iDracInventory  *dell.IDracInventory
 }
 
+var (
+	_ devices.PowerController = (*IDrac8)(nil)
+	_ devices.Inventory       = (*IDrac8)(nil)
+)
+
 // New returns a new IDrac8 ready to be used
 func New(ip string, username string, password string) (iDrac *IDrac8, err error) {
 	return &IDrac8{ip: ip, username: username, password: password}, err
@@ -50,6 +69,9 @@ func New(ip string, username string, password string) (iDrac *IDrac8, err error)
 
 // CheckCredentials verify whether the credentials are valid or not
 func (i *IDrac8) CheckCredentials() (err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "CheckCredentials", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return err
@@ -237,6 +259,9 @@ func (i *IDrac8) get(endpoint string, extraHeaders *map[string]string) (payload
 
 // Nics returns all found Nics in the device
 func (i *IDrac8) Nics() (nics []*devices.Nic, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "Nics", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return nics, err
@@ -288,8 +313,13 @@ func (i *IDrac8) Nics() (nics []*devices.Nic, err error) {
 	return nics, err
 }
 
-// Serial returns the device serial
+// Serial returns the device serial, the service tag in lower case. It's read from the hardware
+// inventory and from racadm getsysinfo when the inventory doesn't list it, an empty service tag
+// returns errors.ErrInvalidSerial
 func (i *IDrac8) Serial() (serial string, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "Serial", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return serial, err
@@ -298,17 +328,22 @@ func (i *IDrac8) Serial() (serial string, err error) {
 	for _, component := range i.iDracInventory.Component {
 		if component.Classname == "DCIM_SystemView" {
 			for _, property := range component.Properties {
-				if property.Name == "NodeID" && property.Type == "string" {
-					return strings.ToLower(property.Value), err
+				if property.Name == "NodeID" && property.Type == "string" && strings.TrimSpace(property.Value) != "" {
+					return strings.ToLower(strings.TrimSpace(property.Value)), err
 				}
 			}
 		}
 	}
-	return serial, err
+
+	serial, err = i.ServiceTag()
+	return strings.ToLower(serial), err
 }
 
 // Status returns health string status from the bmc
 func (i *IDrac8) Status() (status string, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "Status", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return status, err
@@ -342,6 +377,9 @@ func (i *IDrac8) Status() (status string, err error) {
 
 // PowerKw returns the current power usage in Kw
 func (i *IDrac8) PowerKw() (power float64, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "PowerKw", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return power, err
@@ -371,8 +409,32 @@ func (i *IDrac8) PowerKw() (power float64, err error) {
 	return power, err
 }
 
-// PowerState returns the current power state of the machine
+// PowerState returns the current power state of the machine as read from the bmc, one of
+// devices.PowerStateOn, devices.PowerStateOff, devices.PowerStatePoweringOn or devices.PowerStatePoweringOff
 func (i *IDrac8) PowerState() (state string, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "PowerState", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return state, err
+	}
+
+	output, err := i.run("racadm serveraction powerstatus")
+	if err != nil {
+		return devices.PowerStateUnknown, fmt.Errorf("%v: %v", err, output)
+	}
+
+	state = parsePowerStatus(output)
+	if state == devices.PowerStateUnknown {
+		return state, fmt.Errorf("unknown power status: %s", strings.TrimSpace(output))
+	}
+
+	return state, err
+}
+
+// inventoryPowerState returns the power state of the machine recorded in the inventory read at login
+func (i *IDrac8) inventoryPowerState() (state string, err error) {
 	err = i.httpLogin()
 	if err != nil {
 		return state, err
@@ -390,8 +452,12 @@ func (i *IDrac8) PowerState() (state string, err error) {
 	return state, err
 }
 
-// BiosVersion returns the current version of the bios
+// BiosVersion returns the current version of the bios, it's read from racadm getsysinfo when
+// the hardware inventory doesn't list it
 func (i *IDrac8) BiosVersion() (version string, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "BiosVersion", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return version, err
@@ -400,18 +466,21 @@ func (i *IDrac8) BiosVersion() (version string, err error) {
 	for _, component := range i.iDracInventory.Component {
 		if component.Classname == "DCIM_SystemView" {
 			for _, property := range component.Properties {
-				if property.Name == "BIOSVersionString" && property.Type == "string" {
+				if property.Name == "BIOSVersionString" && property.Type == "string" && property.Value != "" {
 					return property.Value, err
 				}
 			}
 		}
 	}
 
-	return version, err
+	return i.sysInfoBiosVersion()
 }
 
 // Name returns the name of this server from the bmc point of view
 func (i *IDrac8) Name() (name string, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "Name", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return name, err
@@ -430,8 +499,12 @@ func (i *IDrac8) Name() (name string, err error) {
 	return name, err
 }
 
-// BmcVersion returns the version of the bmc we are running
+// BmcVersion returns the version of the bmc we are running, it's read from racadm getsysinfo when
+// the hardware inventory doesn't list it
 func (i *IDrac8) BmcVersion() (bmcVersion string, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "BmcVersion", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return bmcVersion, err
@@ -440,17 +513,21 @@ func (i *IDrac8) BmcVersion() (bmcVersion string, err error) {
 	for _, component := range i.iDracInventory.Component {
 		if component.Classname == "DCIM_iDRACCardView" {
 			for _, property := range component.Properties {
-				if property.Name == "FirmwareVersion" && property.Type == "string" {
+				if property.Name == "FirmwareVersion" && property.Type == "string" && property.Value != "" {
 					return property.Value, err
 				}
 			}
 		}
 	}
-	return bmcVersion, err
+
+	return i.sysInfoBmcVersion()
 }
 
 // Model returns the device model
 func (i *IDrac8) Model() (model string, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "Model", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return model, err
@@ -475,6 +552,9 @@ func (i *IDrac8) BmcType() (bmcType string) {
 
 // License returns the bmc license information
 func (i *IDrac8) License() (name string, licType string, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "License", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return name, licType, err
@@ -505,6 +585,9 @@ func (i *IDrac8) License() (name string, licType string, err error) {
 
 // Memory return the total amount of memory of the server
 func (i *IDrac8) Memory() (mem int, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "Memory", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return mem, err
@@ -528,6 +611,9 @@ func (i *IDrac8) Memory() (mem int, err error) {
 
 // Disks returns a list of disks installed on the device
 func (i *IDrac8) Disks() (disks []*devices.Disk, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "Disks", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return disks, err
@@ -576,8 +662,48 @@ func (i *IDrac8) Disks() (disks []*devices.Disk, err error) {
 	return disks, err
 }
 
+// PCIeDevices returns the devices found in the pci express bus, the iDrac doesn't report the
+// negotiated link speed so LinkSpeed carries the slot type, e.g. PCI Express Gen 3
+func (i *IDrac8) PCIeDevices() (pcieDevices []*devices.PCIeDevice, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "PCIeDevices", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.httpLogin()
+	if err != nil {
+		return pcieDevices, err
+	}
+
+	for _, component := range i.iDracInventory.Component {
+		if component.Classname == "DCIM_PCIDeviceView" {
+			pcieDevice := &devices.PCIeDevice{}
+
+			for _, property := range component.Properties {
+				if property.Name == "DeviceDescription" {
+					pcieDevice.Slot = property.DisplayValue
+				} else if property.Name == "PCIVendorID" {
+					pcieDevice.VendorID = strings.ToLower(property.Value)
+				} else if property.Name == "PCIDeviceID" {
+					pcieDevice.DeviceID = strings.ToLower(property.Value)
+				} else if property.Name == "Description" {
+					pcieDevice.Description = property.DisplayValue
+				} else if property.Name == "DataBusWidth" {
+					pcieDevice.LinkWidth = property.DisplayValue
+				} else if property.Name == "SlotType" {
+					pcieDevice.LinkSpeed = property.DisplayValue
+				}
+			}
+
+			pcieDevices = append(pcieDevices, pcieDevice)
+		}
+	}
+	return pcieDevices, err
+}
+
 // TempC returns the current temperature of the machine
 func (i *IDrac8) TempC() (temp int, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "TempC", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return temp, err
@@ -605,6 +731,9 @@ func (i *IDrac8) TempC() (temp int, err error) {
 
 // CPU return the cpu, cores and hyperthreads the server
 func (i *IDrac8) CPU() (cpu string, cpuCount int, coreCount int, hyperthreadCount int, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "CPU", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return cpu, cpuCount, coreCount, hyperthreadCount, err
@@ -642,6 +771,9 @@ func (i *IDrac8) CPU() (cpu string, cpuCount int, coreCount int, hyperthreadCoun
 
 // IsBlade returns if the current hardware is a blade or not
 func (i *IDrac8) IsBlade() (isBlade bool, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "IsBlade", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return isBlade, err
@@ -661,6 +793,9 @@ func (i *IDrac8) IsBlade() (isBlade bool, err error) {
 
 // Psus returns a list of psus installed on the device
 func (i *IDrac8) Psus() (psus []*devices.Psu, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "Psus", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return psus, err
@@ -705,13 +840,60 @@ func (i *IDrac8) Psus() (psus []*devices.Psu, err error) {
 	return psus, err
 }
 
+// PSUs returns the detailed status of the power supplies, OutputWatts is the rated output
+// as the iDrac inventory doesn't expose the current one. The inventory only lists the fitted
+// power supplies, PowerSupplies lists the empty slots too
+func (i *IDrac8) PSUs() (psus []*devices.PowerSupply, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "PSUs", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.httpLogin()
+	if err != nil {
+		return psus, err
+	}
+
+	for _, component := range i.iDracInventory.Component {
+		if component.Classname == "DCIM_PowerSupplyView" {
+			psu := &devices.PowerSupply{Present: true}
+
+			for _, property := range component.Properties {
+				if property.Name == "FQDD" {
+					psu.ID = property.Value
+				} else if property.Name == "Model" {
+					psu.Model = strings.TrimSpace(property.Value)
+				} else if property.Name == "PrimaryStatus" {
+					psu.Status = property.DisplayValue
+				} else if property.Name == "TotalOutputPower" {
+					psu.OutputWatts, _ = strconv.Atoi(property.Value)
+				} else if property.Name == "InputVoltage" {
+					psu.InputVoltage, _ = strconv.Atoi(property.Value)
+				} else if property.Name == "RedundancyStatus" {
+					psu.Redundant = property.DisplayValue == "Fully Redundant"
+				}
+			}
+
+			psus = append(psus, psu)
+		}
+	}
+
+	return psus, err
+}
+
 // Vendor returns bmc's vendor
 func (i *IDrac8) Vendor() (vendor string) {
 	return dell.VendorID
 }
 
+// SupportedActions returns the names of the action methods implemented by this provider
+func (i *IDrac8) SupportedActions() (actions []string) {
+	return helper.SupportedActions(i)
+}
+
 // ServerSnapshot do best effort to populate the server data and returns a blade or discrete
 func (i *IDrac8) ServerSnapshot() (server interface{}, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "ServerSnapshot", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return server, err
@@ -771,7 +953,7 @@ func (i *IDrac8) ServerSnapshot() (server interface{}, err error) {
 		if err != nil {
 			return nil, err
 		}
-		blade.PowerState, err = i.PowerState()
+		blade.PowerState, err = i.inventoryPowerState()
 		if err != nil {
 			return nil, err
 		}
@@ -834,7 +1016,7 @@ func (i *IDrac8) ServerSnapshot() (server interface{}, err error) {
 		if err != nil {
 			return nil, err
 		}
-		discrete.PowerState, err = i.PowerState()
+		discrete.PowerState, err = i.inventoryPowerState()
 		if err != nil {
 			return nil, err
 		}
@@ -1,12 +1,16 @@
 package idrac8
 
 import (
+	"bytes"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 
+	"github.com/bmc-toolbox/bmclib/cfgresources"
 	"github.com/bmc-toolbox/bmclib/devices"
+	"github.com/bmc-toolbox/bmclib/errors"
 	"github.com/spf13/viper"
 )
 
@@ -5214,12 +5218,13 @@ var (
 			<fwVersion>00.16.4F</fwVersion>
 			</sensor></psSensorList><status>ok</status>
 			</root>`),
-		"/sysmgmt/2012/server/license":         []byte(`{"License":{"AUTO_DISCOVERY":1,"BACKUP_RESTORE":1,"BASIC_REMOTE_INVENTORY_EXPORT":1,"BOOT_CAPTURE":1,"CONSOLE_COLLABORATION":1,"DEDICATED_NIC":1,"DEVICE_MONITORING":1,"DIRECTORY_SERVICES":1,"DYNAMIC_DNS":1,"EMAIL_ALERTING":1,"FULL_UI":1,"INBAND_FIRMWARE_UPDATE":1,"IPV6":1,"LAST_CRASH_SCREEN_CAPTURE":1,"LAST_CRASH_VIDEO_CAPTURE":1,"LICENSE_UI":1,"NTP":1,"PART_REPLACEMENT":1,"POWER_BUDGETING":1,"POWER_MONITORING":1,"RACADM_CLI":1,"REMOTE_ASSET_INVENTORY":1,"REMOTE_CONFIGURATION":1,"REMOTE_FILE_SHARE":1,"REMOTE_FIRWARE_UPDATE":1,"REMOTE_OS_DEPLOYMENT":1,"REMOTE_SYSLOG":1,"SECURITY_LOCKOUT":1,"SMASH_CLP":1,"SNMP":1,"SSH":1,"SSH_PK_AUTHEN":1,"SSO":1,"STORAGE_MONITORING":1,"TELNET":1,"TWO_FACTOR_AUTHEN":1,"USC_ASSISTED_OS_DEPLOYEMENT":1,"USC_DEVICE_CONFIGURATION":1,"USC_EMBEDDED_DIAGNOSTICS":1,"USC_FIRMWARE_UPDATE":1,"VCONSOLE":1,"VFOLDER":1,"VIRTUAL_FLASH_PARTITIONS":1,"VIRTUAL_NW_CONSOLE":1,"VMEDIA":1,"WSMAN":1}}`),
-		"/sysmgmt/2012/server/processor":       []byte(`{"Processor":{"D2||CPU.Socket.1":{"brand":"Intel(R) Xeon(R) CPU E5-2690 v4 @ 2.60GHz","cache":"/sysmgmt/2012/server/cache?processor=D2||CPU.Socket.1","core_count":14,"current_speed":2600,"device_description":"CPU 1","executeDisable":[{"capable":1,"enabled":1}],"hyperThreading":[{"capable":1,"enabled":1}],"name":"[CPU1]","state":3,"status":2,"turboMode":[{"capable":1,"enabled":1}],"version":"Model 79 Stepping 1","virtualizationTech":[{"capable":1,"enabled":1}]},"D2||CPU.Socket.2":{"brand":"Intel(R) Xeon(R) CPU E5-2690 v4 @ 2.60GHz","cache":"/sysmgmt/2012/server/cache?processor=D2||CPU.Socket.2","core_count":14,"current_speed":2600,"device_description":"CPU 2","executeDisable":[{"capable":1,"enabled":1}],"hyperThreading":[{"capable":1,"enabled":1}],"name":"[CPU2]","state":3,"status":2,"turboMode":[{"capable":1,"enabled":1}],"version":"Model 79 Stepping 1","virtualizationTech":[{"capable":1,"enabled":1}]}}}`),
-		"/sysmgmt/2012/server/temperature":     []byte(`{"Statistics":"/sysmgmt/2012/server/temperature/statistics","Temperatures":{"iDRAC.Embedded.1#CPU1Temp":{"max_failure":103,"max_warning":98,"max_warning_settable":0,"min_failure":3,"min_warning":8,"min_warning_settable":0,"name":"CPU1 Temp","reading":46,"sensor_status":2},"iDRAC.Embedded.1#CPU2Temp":{"max_failure":103,"max_warning":98,"max_warning_settable":0,"min_failure":3,"min_warning":8,"min_warning_settable":0,"name":"CPU2 Temp","reading":41,"sensor_status":2},"iDRAC.Embedded.1#SystemBoardInletTemp":{"max_failure":47,"max_warning":42,"max_warning_settable":1,"min_failure":-7,"min_warning":3,"min_warning_settable":1,"name":"System Board Inlet Temp","reading":19,"sensor_status":2}},"is_fresh_air_compliant":1}`),
-		"/data/logout":                         []byte(``),
-		"/data/login":                          []byte(`<?xml version="1.0" encoding="UTF-8"?> <root> <status>ok</status> <authResult>0</authResult> <forwardUrl>index.html?ST1=3fd2ec4d84e406f348972d2fb5a1cdd2,ST2=a47f9a0ea441fdd5bf59c63f902c03d2</forwardUrl> </root>`),
-		"/sysmgmt/2016/server/extended_health": []byte(`{"healthStatus":[2,2,0,0,0,0,2,0,2,2,2,2,2,2,0,2,2]}`),
+		"/sysmgmt/2012/server/license":                  []byte(`{"License":{"AUTO_DISCOVERY":1,"BACKUP_RESTORE":1,"BASIC_REMOTE_INVENTORY_EXPORT":1,"BOOT_CAPTURE":1,"CONSOLE_COLLABORATION":1,"DEDICATED_NIC":1,"DEVICE_MONITORING":1,"DIRECTORY_SERVICES":1,"DYNAMIC_DNS":1,"EMAIL_ALERTING":1,"FULL_UI":1,"INBAND_FIRMWARE_UPDATE":1,"IPV6":1,"LAST_CRASH_SCREEN_CAPTURE":1,"LAST_CRASH_VIDEO_CAPTURE":1,"LICENSE_UI":1,"NTP":1,"PART_REPLACEMENT":1,"POWER_BUDGETING":1,"POWER_MONITORING":1,"RACADM_CLI":1,"REMOTE_ASSET_INVENTORY":1,"REMOTE_CONFIGURATION":1,"REMOTE_FILE_SHARE":1,"REMOTE_FIRWARE_UPDATE":1,"REMOTE_OS_DEPLOYMENT":1,"REMOTE_SYSLOG":1,"SECURITY_LOCKOUT":1,"SMASH_CLP":1,"SNMP":1,"SSH":1,"SSH_PK_AUTHEN":1,"SSO":1,"STORAGE_MONITORING":1,"TELNET":1,"TWO_FACTOR_AUTHEN":1,"USC_ASSISTED_OS_DEPLOYEMENT":1,"USC_DEVICE_CONFIGURATION":1,"USC_EMBEDDED_DIAGNOSTICS":1,"USC_FIRMWARE_UPDATE":1,"VCONSOLE":1,"VFOLDER":1,"VIRTUAL_FLASH_PARTITIONS":1,"VIRTUAL_NW_CONSOLE":1,"VMEDIA":1,"WSMAN":1}}`),
+		"/sysmgmt/2012/server/processor":                []byte(`{"Processor":{"D2||CPU.Socket.1":{"brand":"Intel(R) Xeon(R) CPU E5-2690 v4 @ 2.60GHz","cache":"/sysmgmt/2012/server/cache?processor=D2||CPU.Socket.1","core_count":14,"current_speed":2600,"device_description":"CPU 1","executeDisable":[{"capable":1,"enabled":1}],"hyperThreading":[{"capable":1,"enabled":1}],"name":"[CPU1]","state":3,"status":2,"turboMode":[{"capable":1,"enabled":1}],"version":"Model 79 Stepping 1","virtualizationTech":[{"capable":1,"enabled":1}]},"D2||CPU.Socket.2":{"brand":"Intel(R) Xeon(R) CPU E5-2690 v4 @ 2.60GHz","cache":"/sysmgmt/2012/server/cache?processor=D2||CPU.Socket.2","core_count":14,"current_speed":2600,"device_description":"CPU 2","executeDisable":[{"capable":1,"enabled":1}],"hyperThreading":[{"capable":1,"enabled":1}],"name":"[CPU2]","state":3,"status":2,"turboMode":[{"capable":1,"enabled":1}],"version":"Model 79 Stepping 1","virtualizationTech":[{"capable":1,"enabled":1}]}}}`),
+		"/sysmgmt/2012/server/temperature":              []byte(`{"Statistics":"/sysmgmt/2012/server/temperature/statistics","Temperatures":{"iDRAC.Embedded.1#CPU1Temp":{"max_failure":103,"max_warning":98,"max_warning_settable":0,"min_failure":3,"min_warning":8,"min_warning_settable":0,"name":"CPU1 Temp","reading":46,"sensor_status":2},"iDRAC.Embedded.1#CPU2Temp":{"max_failure":103,"max_warning":98,"max_warning_settable":0,"min_failure":3,"min_warning":8,"min_warning_settable":0,"name":"CPU2 Temp","reading":41,"sensor_status":2},"iDRAC.Embedded.1#SystemBoardInletTemp":{"max_failure":47,"max_warning":42,"max_warning_settable":1,"min_failure":-7,"min_warning":3,"min_warning_settable":1,"name":"System Board Inlet Temp","reading":19,"sensor_status":2}},"is_fresh_air_compliant":1}`),
+		"/data/logout":                                  []byte(``),
+		"/data/login":                                   []byte(`<?xml version="1.0" encoding="UTF-8"?> <root> <status>ok</status> <authResult>0</authResult> <forwardUrl>index.html?ST1=3fd2ec4d84e406f348972d2fb5a1cdd2,ST2=a47f9a0ea441fdd5bf59c63f902c03d2</forwardUrl> </root>`),
+		"/sysmgmt/2016/server/extended_health":          []byte(`{"healthStatus":[2,2,0,0,0,0,2,0,2,2,2,2,2,2,0,2,2]}`),
+		"/sysmgmt/2012/server/configgroup/iDRAC.SysLog": []byte(`{"iDRAC.SysLog":{"Port":"514","SysLogEnable":"Enabled","Server1":"syslog.example.com","Server2":"","Server3":""}}`),
 	}
 )
 
@@ -5668,9 +5673,9 @@ func TestIDracPoweState(t *testing.T) {
 		t.Fatalf("Found errors during the test setup %v", err)
 	}
 
-	answer, err := bmc.PowerState()
+	answer, err := bmc.inventoryPowerState()
 	if err != nil {
-		t.Fatalf("Found errors calling bmc.PowerState %v", err)
+		t.Fatalf("Found errors calling bmc.inventoryPowerState %v", err)
 	}
 
 	if expectedAnswer != answer {
@@ -5707,3 +5712,286 @@ func TestUpdateCredentials(t *testing.T) {
 
 	tearDown()
 }
+
+func TestIDracDiffCfg(t *testing.T) {
+	expectedAnswer := []cfgresources.ConfigDiff{
+		{Field: "User.automation.Enable", Current: "Absent", Desired: "Enabled"},
+		{Field: "User.automation.Role", Current: "", Desired: "admin"},
+		{Field: "Syslog.Port", Current: "514", Desired: "1514"},
+	}
+
+	bmc, err := setup()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+
+	config := &cfgresources.ResourcesConfig{
+		User: []*cfgresources.User{
+			{Name: "automation", Password: "secret", Role: "admin", Enable: true},
+			{Name: "legacy", Password: "secret", Role: "user", Enable: false},
+		},
+		Syslog: &cfgresources.Syslog{Server: "syslog.example.com", Port: 1514, Enable: true},
+	}
+
+	answer, err := bmc.DiffCfg(config)
+	if err != nil {
+		t.Fatalf("Found errors calling bmc.DiffCfg %v", err)
+	}
+
+	if !reflect.DeepEqual(answer, expectedAnswer) {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, answer)
+	}
+
+	tearDown()
+}
+
+func TestIDracDiffCfgSections(t *testing.T) {
+	expectedAnswer := []cfgresources.ConfigDiff{
+		{Field: "Network.IpmiEnable", Current: "1", Desired: "0"},
+		{Field: "Ntp.Timezone", Current: "UTC", Desired: "CET"},
+		{Field: "Ntp.Server2", Current: "", Desired: "ntp1.example.com"},
+		{Field: "Ldap.SearchFilter", Current: "objectClass=account", Desired: "objectClass=posixAccount"},
+		{Field: "LdapGroup.2.Group", Current: "", Desired: "cn=bmcUsers,ou=Group,dc=example,dc=com"},
+		{Field: "LdapGroup.2.Privilege", Current: "0", Desired: "497"},
+		{Field: "Ldap.Port", Current: "389", Desired: "636"},
+	}
+
+	data := answers["/data"]
+	defer func() { answers["/data"] = data }()
+	answers["/data"] = []byte(`<?xml version="1.0" encoding="UTF-8"?><root>
+		<dhcpForDNSDomain>1</dhcpForDNSDomain><ipmiLAN>1</ipmiLAN><serialOverLanEnabled>1</serialOverLanEnabled><racRedirectEna>1</racRedirectEna>
+		<tm_tz_str_zone>UTC</tm_tz_str_zone><tm_ntp_int_opmode>1</tm_ntp_int_opmode><tm_ntp_str_server1>ntp0.example.com</tm_ntp_str_server1><tm_ntp_str_server2></tm_ntp_str_server2><tm_ntp_str_server3></tm_ntp_str_server3>
+		<xGLServer>ldap.example.com</xGLServer><xGLSearchFilter>objectClass=account</xGLSearchFilter>
+		<LDAPEnableMode>3</LDAPEnableMode><xGLBaseDN>ou=People,dc=example,dc=com</xGLBaseDN><xGLUserLogin>uid</xGLUserLogin><xGLGroupMem>memberUid</xGLGroupMem><xGLBindDN></xGLBindDN><xGLServerPort>389</xGLServerPort>
+		<xGLGroup1Name>cn=bmcAdmins,ou=Group,dc=example,dc=com</xGLGroup1Name><xGLGroup1Priv>511</xGLGroup1Priv>
+		<xGLGroup2Name></xGLGroup2Name><xGLGroup2Priv>0</xGLGroup2Priv><xGLGroup3Name></xGLGroup3Name><xGLGroup3Priv>0</xGLGroup3Priv>
+		<xGLGroup4Name></xGLGroup4Name><xGLGroup4Priv>0</xGLGroup4Priv><xGLGroup5Name></xGLGroup5Name><xGLGroup5Priv>0</xGLGroup5Priv>
+		<status>ok</status></root>`)
+
+	bmc, err := setup()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+
+	ldap := &cfgresources.Ldap{
+		Server:         "ldap.example.com",
+		Port:           636,
+		BaseDn:         "ou=People,dc=example,dc=com",
+		UserAttribute:  "uid",
+		GroupAttribute: "memberUid",
+		SearchFilter:   "objectClass=posixAccount",
+	}
+
+	config := &cfgresources.ResourcesConfig{
+		Network: &cfgresources.Network{DNSFromDHCP: true, SolEnable: true},
+		Ntp:     &cfgresources.Ntp{Enable: true, Server1: "ntp0.example.com", Server2: "ntp1.example.com", Timezone: "CET"},
+		Ldap:    ldap,
+		LdapGroup: []*cfgresources.LdapGroup{
+			{Role: "admin", Group: "cn=bmcAdmins", GroupBaseDn: "ou=Group,dc=example,dc=com", Enable: true},
+			{Role: "user", Group: "cn=bmcUsers", GroupBaseDn: "ou=Group,dc=example,dc=com", Enable: true},
+		},
+	}
+
+	answer, err := bmc.DiffCfg(config)
+	if err != nil {
+		t.Fatalf("Found errors calling bmc.DiffCfg %v", err)
+	}
+
+	if !reflect.DeepEqual(answer, expectedAnswer) {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, answer)
+	}
+
+	_, err = bmc.DiffCfg(&cfgresources.ResourcesConfig{LdapGroup: config.LdapGroup})
+	if err == nil {
+		t.Errorf("Expected an error comparing the ldap groups without the ldap section")
+	}
+
+	tearDown()
+}
+
+func TestIDracDiffCfgMissingParam(t *testing.T) {
+	bmc, err := setup()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+
+	_, err = bmc.DiffCfg(&cfgresources.ResourcesConfig{Network: &cfgresources.Network{IpmiEnable: true}})
+	if err == nil || !strings.Contains(err.Error(), "dhcpForDNSDomain") {
+		t.Errorf("Expected an error naming the missing parameter: found %v", err)
+	}
+
+	tearDown()
+}
+
+func TestIDracPCIeDevices(t *testing.T) {
+	expectedAnswer := &devices.PCIeDevice{
+		Slot:        "Integrated Storage Controller 1",
+		VendorID:    "1000",
+		DeviceID:    "0097",
+		Description: "SAS3008 PCI-Express Fusion-MPT SAS-3",
+		LinkWidth:   "Unknown",
+		LinkSpeed:   "Unknown",
+	}
+
+	bmc, err := setup()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+
+	pcieDevices, err := bmc.PCIeDevices()
+	if err != nil {
+		t.Fatalf("Found errors calling bmc.PCIeDevices %v", err)
+	}
+
+	if len(pcieDevices) != 19 {
+		t.Errorf("Expected 19 pcie devices: found %d", len(pcieDevices))
+	}
+
+	if len(pcieDevices) > 0 && !reflect.DeepEqual(pcieDevices[0], expectedAnswer) {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, pcieDevices[0])
+	}
+
+	tearDown()
+}
+
+func TestIDracPSUs(t *testing.T) {
+	expectedAnswer := []*devices.PowerSupply{
+		{ID: "PSU.Slot.1", Model: "PWR SPLY,750W,RDNT,ARTESYN", Status: "OK", OutputWatts: 750, InputVoltage: 236, Redundant: true, Present: true},
+		{ID: "PSU.Slot.2", Model: "PWR SPLY,750W,RDNT,ARTESYN", Status: "OK", OutputWatts: 750, InputVoltage: 234, Redundant: true, Present: true},
+	}
+
+	bmc, err := setup()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+
+	psus, err := bmc.PSUs()
+	if err != nil {
+		t.Fatalf("Found errors calling bmc.PSUs %v", err)
+	}
+
+	if !reflect.DeepEqual(psus, expectedAnswer) {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, psus)
+	}
+
+	tearDown()
+}
+
+func TestIDracLastCrashScreen(t *testing.T) {
+	expectedAnswer := append(append([]byte{}, pngSignature...), "IHDR"...)
+	expectedCommands := []string{"racadm get iDRAC.ASRConfig.Enable"}
+
+	answers["/capconsole/lcs.png"] = expectedAnswer
+	defer delete(answers, "/capconsole/lcs.png")
+
+	bmc, err := setup()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+	defer tearDown()
+
+	runner := &fakeRunner{answers: map[string]string{"racadm get iDRAC.ASRConfig.Enable": "Enable=Enabled"}}
+	bmc.SetRunner(runner)
+
+	answer, err := bmc.LastCrashScreen()
+	if err != nil {
+		t.Fatalf("Found errors calling bmc.LastCrashScreen %v", err)
+	}
+
+	if !bytes.Equal(answer, expectedAnswer) {
+		t.Errorf("Expected answer %q: found %q", expectedAnswer, answer)
+	}
+
+	if !reflect.DeepEqual(runner.commands, expectedCommands) {
+		t.Errorf("Expected answer %v: found %v", expectedCommands, runner.commands)
+	}
+}
+
+func TestIDracLastCrashScreenNoData(t *testing.T) {
+	tt := []struct {
+		name   string
+		enable string
+		image  []byte
+	}{
+		{name: "capture disabled", enable: "Enable=Disabled", image: append(append([]byte{}, pngSignature...), "IHDR"...)},
+		{name: "no crash screen stored", enable: "Enable=Enabled"},
+		{name: "not an image", enable: "Enable=Enabled", image: []byte("<html><body>No crash screen</body></html>")},
+	}
+
+	for _, tc := range tt {
+		if tc.image != nil {
+			answers["/capconsole/lcs.png"] = tc.image
+		}
+
+		bmc, err := setup()
+		if err != nil {
+			t.Fatalf("Found errors during the test setup %v", err)
+		}
+
+		bmc.SetRunner(&fakeRunner{answers: map[string]string{"racadm get iDRAC.ASRConfig.Enable": tc.enable}})
+
+		answer, err := bmc.LastCrashScreen()
+		if err != errors.ErrNoData || answer != nil {
+			t.Errorf("%s: Expected answer %v: found %q, %v", tc.name, errors.ErrNoData, answer, err)
+		}
+
+		tearDown()
+		delete(answers, "/capconsole/lcs.png")
+	}
+}
+
+func TestIDracUserSlots(t *testing.T) {
+	expectedAnswer := []UserSlot{
+		{Index: 2, UserName: "root", Role: "admin", Enabled: true},
+		{Index: 3, UserName: "legacy", Role: "user", Enabled: false},
+	}
+
+	data := answers["/data"]
+	defer func() { answers["/data"] = data }()
+	answers["/data"] = []byte(`<?xml version="1.0" encoding="UTF-8"?><root>
+		<user><id>2</id><name>root</name><privileges>511</privileges><enabled>1</enabled><solEnabled>1</solEnabled></user>
+		<user><id>3</id><name>legacy</name><privileges>499</privileges><enabled>0</enabled><solEnabled>0</solEnabled></user>
+		<user><id>4</id><name></name><privileges>0</privileges><enabled>0</enabled><solEnabled>0</solEnabled></user>
+		<status>ok</status></root>`)
+	answers["/sysmgmt/2012/server/configgroup/iDRAC.Users.3"] = []byte(`{}`)
+	answers["/sysmgmt/2012/server/configgroup/iDRAC.Users.4"] = []byte(`{}`)
+	defer delete(answers, "/sysmgmt/2012/server/configgroup/iDRAC.Users.3")
+	defer delete(answers, "/sysmgmt/2012/server/configgroup/iDRAC.Users.4")
+
+	bmc, err := setup()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+	defer tearDown()
+
+	answer, err := bmc.ListUsers()
+	if err != nil {
+		t.Fatalf("Found errors calling bmc.ListUsers %v", err)
+	}
+
+	if !reflect.DeepEqual(answer, expectedAnswer) {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, answer)
+	}
+
+	for _, index := range []int{0, 1, 17} {
+		if err := bmc.CreateUserInSlot(index, "automation", "secret", "admin", false); err == nil {
+			t.Errorf("Expected an error creating the user in slot %d", index)
+		}
+	}
+
+	if err := bmc.CreateUserInSlot(3, "automation", "secret", "admin", false); err == nil {
+		t.Errorf("Expected an error overwriting the user in slot 3 without replace")
+	}
+
+	if err := bmc.CreateUserInSlot(4, "root", "secret", "admin", false); err == nil {
+		t.Errorf("Expected an error creating a user already present in another slot")
+	}
+
+	if err := bmc.CreateUserInSlot(3, "automation", "secret", "admin", true); err != nil {
+		t.Errorf("Found errors calling bmc.CreateUserInSlot %v", err)
+	}
+
+	if err := bmc.CreateUserInSlot(4, "automation2", "secret", "user", false); err != nil {
+		t.Errorf("Found errors calling bmc.CreateUserInSlot %v", err)
+	}
+}
@@ -2,10 +2,21 @@ package idrac8
 
 import (
 	"encoding/xml"
+	"time"
+
+	"github.com/bmc-toolbox/bmclib/devices"
 )
 
 type UserInfo map[int]User
 
+// UserSlot is a user account as returned by ListUsers along with the slot holding it
+type UserSlot struct {
+	Index    int
+	UserName string
+	Role     string
+	Enabled  bool
+}
+
 //https://10.193.251.5/sysmgmt/2012/server/configgroup/iDRAC.SysLog
 type Syslog struct {
 	Port    string `json:"Port"`
@@ -41,6 +52,17 @@ type XmlRoot struct {
 	Status         string           `xml:"status"`
 }
 
+// XmlData holds the parameters returned by data?get=, keyed by their element name
+type XmlData struct {
+	XMLName xml.Name       `xml:"root"`
+	Params  []XmlDataParam `xml:",any"`
+}
+
+type XmlDataParam struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
 type XmlUserAccount struct {
 	Name          string `xml:"name"`
 	Id            int    `xml:"id"`
@@ -52,3 +74,103 @@ type XmlUserAccount struct {
 	SnmpV3Enabled int    `xml:"SNMPV3Enabled"`
 	SnmpPrivType  int    `xml:"snmpPrivType"`
 }
+
+// SELEntry is a single record of the iDrac System Event Log
+type SELEntry struct {
+	ID        int       `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Source    string    `json:"source"`
+	Severity  string    `json:"severity"`
+	Message   string    `json:"message"`
+}
+
+// SELFilter narrows down the SEL records returned by GetSELFiltered, zero values match everything
+type SELFilter struct {
+	// MinSeverity is one of Ok, Non-Critical or Critical
+	MinSeverity string
+	Since       time.Time
+	Until       time.Time
+	// Sensor is matched case insensitive against the source and the message of the record
+	Sensor string
+}
+
+// Sensor is a reading of the temperature probes listed by racadm getsensorinfo
+type Sensor struct {
+	Name    string  `json:"name"`
+	Reading float64 `json:"reading"`
+	Units   string  `json:"units"`
+	Status  string  `json:"status"`
+}
+
+// Fan is the speed of a fan listed by racadm getsensorinfo
+type Fan struct {
+	Name   string `json:"name"`
+	RPM    int    `json:"rpm"`
+	Status string `json:"status"`
+}
+
+// Device holds the facts about the server listed by racadm getsysinfo
+type Device struct {
+	ServiceTag  string         `json:"service_tag"`
+	Model       string         `json:"model"`
+	BiosVersion string         `json:"bios_version"`
+	BmcVersion  string         `json:"bmc_version"`
+	BmcMAC      string         `json:"bmc_mac"`
+	Nics        []*devices.Nic `json:"nics"`
+	PowerState  string         `json:"power_state"`
+}
+
+// LicenseInfo is the license installed in the idrac as listed by racadm license view, Expires is
+// zero for the perpetual licenses
+type LicenseInfo struct {
+	Type    string    `json:"type"`
+	Status  string    `json:"status"`
+	Expires time.Time `json:"expires"`
+}
+
+// JobState is the progress of a lifecycle controller job listed by racadm jobqueue view, State is
+// one of Running, Completed or Failed while Status is the one printed by the idrac, e.g. Downloading
+type JobState struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	State           string `json:"state"`
+	Status          string `json:"status"`
+	Message         string `json:"message"`
+	PercentComplete int    `json:"percent_complete"`
+}
+
+// HealthStatus is the health of the server components as rolled up by racadm rollupstatus, each
+// subsystem is one of OK, Warning or Critical and Overall is the worst of them
+type HealthStatus struct {
+	Overall    string            `json:"overall"`
+	Subsystems map[string]string `json:"subsystems"`
+}
+
+// PSU is a power supply slot as listed by racadm getsensorinfo, the empty slots are listed too with
+// Present false. InputWatts is the maximum input power the power supply is rated for
+type PSU struct {
+	Name       string  `json:"name"`
+	Status     string  `json:"status"`
+	InputWatts float64 `json:"input_watts"`
+	Present    bool    `json:"present"`
+}
+
+// DIMM is a memory module listed by racadm hwinventory, Status is the one printed by the idrac,
+// e.g. Ok, Warning or Critical for a failing module
+type DIMM struct {
+	Slot         string `json:"slot"`
+	SizeMB       int    `json:"size_mb"`
+	SpeedMHz     int    `json:"speed_mhz"`
+	Manufacturer string `json:"manufacturer"`
+	Serial       string `json:"serial"`
+	Status       string `json:"status"`
+}
+
+// CPU is a processor socket listed by racadm hwinventory, SpeedMHz is the current clock speed
+type CPU struct {
+	Socket   string `json:"socket"`
+	Model    string `json:"model"`
+	Cores    int    `json:"cores"`
+	SpeedMHz int    `json:"speed_mhz"`
+	Status   string `json:"status"`
+}
@@ -1,18 +1,30 @@
 package idrac8
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"encoding/xml"
 	"fmt"
+	"net"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/bmc-toolbox/bmclib/devices"
+	"github.com/bmc-toolbox/bmclib/errors"
 	"github.com/bmc-toolbox/bmclib/internal/helper"
+	"github.com/bmc-toolbox/bmclib/internal/tracing"
+	"github.com/bmc-toolbox/bmclib/providers/dell"
 	log "github.com/sirupsen/logrus"
 )
 
 // Grab screen preview.
 func (i *IDrac8) Screenshot() (response []byte, extension string, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "Screenshot", i.ip)
+	defer func() { tracing.End(span, err) }()
 
 	endpoint1 := fmt.Sprintf("data?get=consolepreview[auto%%20%d]",
 		time.Now().UnixNano()/int64(time.Millisecond))
@@ -40,6 +52,45 @@ func (i *IDrac8) Screenshot() (response []byte, extension string, err error) {
 	return response, extension, err
 }
 
+// LastCrashScreen returns the png of the screen captured when the automatic system recovery last reset the
+// server. errors.ErrNoData is returned when the capture is disabled or no crash screen is stored
+func (i *IDrac8) LastCrashScreen() (image []byte, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "LastCrashScreen", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return image, err
+	}
+
+	// the crash screen is only captured with the automatic system recovery enabled
+	command := "racadm get iDRAC.ASRConfig.Enable"
+	output, err := i.run(command)
+	if err != nil {
+		return image, &errors.CommandError{Cmd: command, Output: output, Err: err}
+	}
+
+	if !strings.Contains(parseRacadmFields(output)["Enable"], "Enabled") {
+		return image, errors.ErrNoData
+	}
+
+	err = i.httpLogin()
+	if err != nil {
+		return image, err
+	}
+
+	// racadm can only clear the stored screen, the image is served by the web interface
+	image, err = i.get(fmt.Sprintf("capconsole/lcs.png?%d", time.Now().UnixNano()/int64(time.Millisecond)), nil)
+	if err == errors.ErrPageNotFound || (err == nil && !bytes.HasPrefix(image, pngSignature)) {
+		return nil, errors.ErrNoData
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return image, err
+}
+
 //Queries Idrac8 for current user accounts
 func (i *IDrac8) queryUsers() (userInfo UserInfo, err error) {
 
@@ -103,3 +154,1236 @@ func (i *IDrac8) queryUsers() (userInfo UserInfo, err error) {
 
 	return userInfo, err
 }
+
+// ListUsers returns the user accounts configured in the idrac along with their slot, sorted by slot
+func (i *IDrac8) ListUsers() (users []UserSlot, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "ListUsers", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.httpLogin()
+	if err != nil {
+		return users, err
+	}
+
+	idracUsers, err := i.queryUsers()
+	if err != nil {
+		return users, err
+	}
+
+	for index, user := range idracUsers {
+		if user.UserName == "" {
+			continue
+		}
+
+		role := "user"
+		if user.Privilege == "511" {
+			role = "admin"
+		}
+
+		users = append(users, UserSlot{Index: index, UserName: user.UserName, Role: role, Enabled: user.Enable == "Enabled"})
+	}
+
+	sort.Slice(users, func(a, b int) bool { return users[a].Index < users[b].Index })
+
+	return users, err
+}
+
+// queryConfigGroup reads the sysmgmt config group ApplyCfg writes to, e.g iDRAC.SysLog, into value
+func (i *IDrac8) queryConfigGroup(group string, value interface{}) (err error) {
+	endpoint := fmt.Sprintf("sysmgmt/2012/server/configgroup/%s", group)
+
+	response, err := i.get(endpoint, nil)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"IP":       i.ip,
+			"Model":    i.BmcType(),
+			"endpoint": endpoint,
+			"step":     helper.WhosCalling(),
+			"Error":    err,
+		}).Warn("GET request failed.")
+		return err
+	}
+
+	data := make(map[string]json.RawMessage)
+	err = json.Unmarshal(response, &data)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"step":     helper.WhosCalling(),
+			"resource": group,
+			"IP":       i.ip,
+			"Model":    i.BmcType(),
+			"Error":    err,
+		}).Warn("Unable to unmarshal payload.")
+		return err
+	}
+
+	payload, ok := data[group]
+	if !ok {
+		return fmt.Errorf("config group %s not found in the bmc response", group)
+	}
+
+	return json.Unmarshal(payload, value)
+}
+
+// queryData reads the given data parameters, the ones ApplyCfg sets through data?set=,
+// a parameter missing from the answer is reported as an error
+func (i *IDrac8) queryData(params ...string) (values map[string]string, err error) {
+	endpoint := fmt.Sprintf("data?get=%s,", strings.Join(params, ","))
+
+	response, err := i.get(endpoint, nil)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"IP":       i.ip,
+			"Model":    i.BmcType(),
+			"endpoint": endpoint,
+			"step":     helper.WhosCalling(),
+			"Error":    err,
+		}).Warn("GET request failed.")
+		return values, err
+	}
+
+	xmlData := XmlData{}
+	err = xml.Unmarshal(response, &xmlData)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"step":     helper.WhosCalling(),
+			"resource": "Data",
+			"IP":       i.ip,
+			"Model":    i.BmcType(),
+			"Error":    err,
+		}).Warn("Unable to unmarshal payload.")
+		return values, err
+	}
+
+	found := make(map[string]string)
+	for _, param := range xmlData.Params {
+		found[param.XMLName.Local] = strings.TrimSpace(param.Value)
+	}
+
+	values = make(map[string]string)
+	for _, param := range params {
+		value, ok := found[param]
+		if !ok {
+			return values, fmt.Errorf("parameter %s not found in the bmc response", param)
+		}
+		values[param] = value
+	}
+
+	return values, err
+}
+
+// StreamSEL reads the System Event Log and emits the records as they are parsed,
+// both channels are closed once the log is fully read or the context is cancelled
+func (i *IDrac8) StreamSEL(ctx context.Context) (<-chan SELEntry, <-chan error) {
+	entries := make(chan SELEntry)
+	errs := make(chan error, 1)
+
+	go func() {
+		var err error
+		span := tracing.Start(tracing.Parent(ctx, i.traceCtx), dell.VendorID, "StreamSEL", i.ip)
+		defer func() { tracing.End(span, err) }()
+		defer close(errs)
+		defer close(entries)
+
+		err = i.sshLogin()
+		if err != nil {
+			errs <- err
+			return
+		}
+
+		stream, err := i.stream("racadm getsel")
+		if err != nil {
+			errs <- err
+			return
+		}
+		defer stream.Close()
+
+		// closing the stream unblocks the parser when the context goes away
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-ctx.Done():
+				stream.Close()
+			case <-done:
+			}
+		}()
+
+		err = parseSEL(stream, func(entry SELEntry) bool {
+			select {
+			case entries <- entry:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+
+		if ctx.Err() != nil {
+			err = ctx.Err()
+			errs <- err
+			return
+		}
+
+		if err != nil {
+			errs <- err
+		}
+	}()
+
+	return entries, errs
+}
+
+// FirmwareInventory returns the firmware version installed in every component reported by the bmc
+func (i *IDrac8) FirmwareInventory() (firmware []devices.FirmwareComponent, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "FirmwareInventory", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return firmware, err
+	}
+
+	output, err := i.run("racadm swinventory")
+	if err != nil {
+		return firmware, &errors.CommandError{Cmd: "racadm swinventory", Output: output, Err: err}
+	}
+
+	return parseSwInventory(output), err
+}
+
+// GetSEL returns all the records of the System Event Log, an empty log returns no records and no error
+func (i *IDrac8) GetSEL() (entries []SELEntry, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "GetSEL", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	entries, err = i.GetSELFiltered(SELFilter{})
+	if err == nil && entries == nil {
+		entries = []SELEntry{}
+	}
+
+	return entries, err
+}
+
+// GetSELFiltered returns the System Event Log records matching the given filter, racadm
+// can't filter by severity, time or sensor so the records are filtered while being parsed
+func (i *IDrac8) GetSELFiltered(filter SELFilter) (entries []SELEntry, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "GetSELFiltered", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	if _, ok := selSeverities[strings.ToLower(filter.MinSeverity)]; filter.MinSeverity != "" && !ok {
+		return entries, fmt.Errorf("unknown sel severity: %s", filter.MinSeverity)
+	}
+
+	err = i.sshLogin()
+	if err != nil {
+		return entries, err
+	}
+
+	output, err := i.run("racadm getsel")
+	if err != nil {
+		return entries, &errors.CommandError{Cmd: "racadm getsel", Output: output, Err: err}
+	}
+
+	err = parseSEL(strings.NewReader(output), func(entry SELEntry) bool {
+		if matchSEL(filter, entry) {
+			entries = append(entries, entry)
+		}
+		return true
+	})
+
+	return entries, err
+}
+
+// BMCTime returns the current time of the bmc clock
+func (i *IDrac8) BMCTime() (t time.Time, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "BMCTime", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return t, err
+	}
+
+	output, err := i.run("racadm getractime -d")
+	if err != nil {
+		return t, &errors.CommandError{Cmd: "racadm getractime -d", Output: output, Err: err}
+	}
+
+	return parseRacTime(output)
+}
+
+// DHCPLeaseInfo tells whether the idrac got its ipv4 address by dhcp, racadm getniccfg
+// doesn't print the dhcp server nor the lease expiry so only Enabled and IPAddress are set
+func (i *IDrac8) DHCPLeaseInfo() (lease *devices.DHCPLease, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "DHCPLeaseInfo", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return lease, err
+	}
+
+	output, err := i.run("racadm getniccfg")
+	if err != nil {
+		return lease, fmt.Errorf("unable to read the nic config: %s", output)
+	}
+
+	fields := parseRacadmFields(output)
+	enabled, ok := fields["DHCP Enabled"]
+	if !ok {
+		return lease, fmt.Errorf("unable to find the dhcp settings: %s", output)
+	}
+
+	return &devices.DHCPLease{Enabled: enabled == "1", IPAddress: fields["IP Address"]}, err
+}
+
+// VirtualMediaStatus returns the state of the remote file share, the only virtual media
+// racadm can attach and always exposed as a cd drive
+func (i *IDrac8) VirtualMediaStatus() (vmedia []devices.VirtualMediaDevice, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "VirtualMediaStatus", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return vmedia, err
+	}
+
+	output, err := i.run("racadm remoteimage -s")
+	if err != nil {
+		return vmedia, fmt.Errorf("unable to read the remote image status: %s", output)
+	}
+
+	device := devices.VirtualMediaDevice{Type: devices.VirtualMediaCD}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "Remote File Share is") {
+			device.Attached = strings.HasSuffix(line, "Enabled")
+		} else if strings.HasPrefix(line, "ShareName") {
+			device.ImageURL = strings.TrimSpace(strings.TrimPrefix(line, "ShareName"))
+		}
+	}
+
+	return append(vmedia, device), err
+}
+
+// ServiceTag returns the dell service tag of the server, upper case as printed on the asset tag
+func (i *IDrac8) ServiceTag() (serviceTag string, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "ServiceTag", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return serviceTag, err
+	}
+
+	output, err := i.run("racadm getsysinfo")
+	if err != nil {
+		return serviceTag, fmt.Errorf("unable to read the system info: %s", output)
+	}
+
+	serviceTag = strings.ToUpper(parseRacadmFields(output)["Service Tag"])
+	if serviceTag == "" {
+		return serviceTag, errors.ErrInvalidSerial
+	}
+
+	return serviceTag, err
+}
+
+// Inventory returns the service tag, model, versions, mac addresses and power state of the server
+// read with a single racadm getsysinfo
+func (i *IDrac8) Inventory() (device *Device, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "Inventory", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return device, err
+	}
+
+	output, err := i.run("racadm getsysinfo")
+	if err != nil {
+		return device, fmt.Errorf("unable to read the system info: %s", output)
+	}
+
+	return parseSysInfo(output)
+}
+
+// parseSysInfo reads the facts listed by racadm getsysinfo, the fields missing from the output
+// of old firmwares are left empty. Each nic is listed in the Embedded NIC MAC Addresses section
+// followed by the WWN of the port
+//
+// Embedded NIC MAC Addresses:
+// NIC.Integrated.1-1-1    Ethernet                = 14:18:77:4a:b3:10
+func parseSysInfo(output string) (device *Device, err error) {
+	fields := parseRacadmFields(output)
+	if fields["Service Tag"] == "" {
+		return device, errors.ErrUnableToReadData
+	}
+
+	device = &Device{
+		ServiceTag:  strings.ToUpper(fields["Service Tag"]),
+		Model:       fields["System Model"],
+		BiosVersion: fields["System BIOS Version"],
+		BmcVersion:  fields["Firmware Version"],
+		BmcMAC:      strings.ToLower(fields["MAC Address"]),
+		Nics:        make([]*devices.Nic, 0),
+	}
+
+	if status, ok := fields["Power Status"]; ok {
+		device.PowerState = parsePowerStatus(status)
+	}
+
+	device.Nics = append(device.Nics, sysInfoNics(output)...)
+
+	return device, err
+}
+
+// sysInfoNics returns the host nics listed by racadm getsysinfo with their mac address,
+// the ones printed without a valid address, e.g. "Not Available", are left out
+//
+// Embedded NIC MAC Addresses:
+// NIC.Integrated.1-1-1    Ethernet = 24:6E:96:0A:8C:10
+func sysInfoNics(output string) (nics []*devices.Nic) {
+	for _, line := range strings.Split(output, "\n") {
+		data := strings.SplitN(line, "=", 2)
+		if len(data) != 2 {
+			continue
+		}
+
+		name := strings.Fields(data[0])
+		if len(name) != 2 || name[1] != "Ethernet" {
+			continue
+		}
+
+		mac, err := net.ParseMAC(strings.TrimSpace(data[1]))
+		if err != nil {
+			continue
+		}
+
+		nics = append(nics, &devices.Nic{
+			Name:       name[0],
+			MacAddress: mac.String(),
+		})
+	}
+
+	return nics
+}
+
+// MacAddresses returns the mac addresses listed by racadm getsysinfo keyed by interface, the one
+// of the bmc is keyed as iDRAC and the host nics by their name, e.g. NIC.Integrated.1-1-1
+func (i *IDrac8) MacAddresses() (macs map[string]string, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "MacAddresses", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return macs, err
+	}
+
+	output, err := i.run("racadm getsysinfo")
+	if err != nil {
+		return macs, fmt.Errorf("unable to read the system info: %s", output)
+	}
+
+	return parseMacAddresses(output), err
+}
+
+// parseMacAddresses returns the mac addresses of the bmc and the host nics listed by racadm getsysinfo,
+// the interfaces without a valid address are left out
+func parseMacAddresses(output string) (macs map[string]string) {
+	macs = make(map[string]string)
+	if mac, err := net.ParseMAC(parseRacadmFields(output)["MAC Address"]); err == nil {
+		macs["iDRAC"] = mac.String()
+	}
+
+	for _, nic := range sysInfoNics(output) {
+		macs[nic.Name] = nic.MacAddress
+	}
+
+	return macs
+}
+
+// sysInfoBmcVersion reads the idrac firmware version from racadm getsysinfo
+func (i *IDrac8) sysInfoBmcVersion() (version string, err error) {
+	err = i.sshLogin()
+	if err != nil {
+		return version, err
+	}
+
+	output, err := i.run("racadm getsysinfo")
+	if err != nil {
+		return version, fmt.Errorf("unable to read the system info: %s", output)
+	}
+
+	return parseBmcVersion(output)
+}
+
+// parseBmcVersion returns the idrac firmware version listed under RAC Information by racadm getsysinfo,
+// the bios version is listed as well but under System Information
+//
+// RAC Information:
+// RAC Date/Time           = Tue Feb 13 2018 10:02:48
+// Firmware Version        = 2.50.33.50
+func parseBmcVersion(output string) (version string, err error) {
+	version = parseRacadmFields(output)["Firmware Version"]
+	if version == "" {
+		return version, fmt.Errorf("the idrac firmware version isn't listed in the system info")
+	}
+
+	return version, err
+}
+
+// sysInfoBiosVersion reads the bios version from racadm getsysinfo
+func (i *IDrac8) sysInfoBiosVersion() (version string, err error) {
+	err = i.sshLogin()
+	if err != nil {
+		return version, err
+	}
+
+	output, err := i.run("racadm getsysinfo")
+	if err != nil {
+		return version, fmt.Errorf("unable to read the system info: %s", output)
+	}
+
+	return parseBiosVersion(output)
+}
+
+// parseBiosVersion returns the System BIOS Version listed by racadm getsysinfo, old firmwares
+// don't list it and get errors.ErrBiosNotFound
+func parseBiosVersion(output string) (version string, err error) {
+	version = parseRacadmFields(output)["System BIOS Version"]
+	if version == "" {
+		return version, errors.ErrBiosNotFound
+	}
+
+	return version, err
+}
+
+// IdentifyLEDState returns whether the identify led of the chassis is blinking
+func (i *IDrac8) IdentifyLEDState() (on bool, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "IdentifyLEDState", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return on, err
+	}
+
+	output, err := i.run("racadm getled")
+	if err != nil {
+		return on, fmt.Errorf("unable to read the led state: %s", output)
+	}
+
+	// LED State : Blinking
+	// LED State : Not-Blinking
+	state := strings.ToLower(output)
+	switch {
+	case strings.Contains(state, "not-blinking"), strings.Contains(state, "not blinking"):
+		return false, err
+	case strings.Contains(state, "blinking"):
+		return true, err
+	}
+
+	return on, fmt.Errorf("unknown led state: %s", output)
+}
+
+// ChassisIntrusion reads the intrusion sensor listed by racadm getsensorinfo, blades have
+// none fitted and get errors.ErrFeatureUnavailable
+func (i *IDrac8) ChassisIntrusion() (status devices.IntrusionStatus, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "ChassisIntrusion", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return devices.IntrusionUnknown, err
+	}
+
+	output, err := i.run("racadm getsensorinfo")
+	if err != nil {
+		return devices.IntrusionUnknown, fmt.Errorf("unable to read the sensors: %s", output)
+	}
+
+	return parseIntrusion(output)
+}
+
+// parseIntrusion reads the INTRUSION section of racadm getsensorinfo
+//
+// Sensor Type : INTRUSION
+// <Sensor Name>                   <Intrusion>          <Status>
+// System Board Intrusion          Closed               Ok
+func parseIntrusion(output string) (status devices.IntrusionStatus, err error) {
+	var section bool
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "Sensor Type") {
+			section = strings.HasSuffix(line, "INTRUSION")
+			continue
+		}
+
+		if !section || line == "" || strings.HasPrefix(line, "<") {
+			continue
+		}
+
+		switch {
+		case strings.Contains(line, "Closed"):
+			return devices.IntrusionClosed, nil
+		case strings.Contains(line, "Open"), strings.Contains(line, "Breach"):
+			return devices.IntrusionOpen, nil
+		default:
+			return devices.IntrusionUnknown, nil
+		}
+	}
+
+	return devices.IntrusionUnknown, errors.ErrFeatureUnavailable
+}
+
+// Health returns the health of the server components along with the worst of them, the firmwares
+// lacking racadm rollupstatus get errors.ErrFeatureUnavailable
+func (i *IDrac8) Health() (health HealthStatus, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "Health", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return health, err
+	}
+
+	output, err := i.run("racadm rollupstatus")
+	if strings.Contains(strings.ToLower(output), "invalid subcommand") {
+		return health, errors.ErrFeatureUnavailable
+	}
+	if err != nil {
+		return health, fmt.Errorf("unable to read the health: %s", output)
+	}
+
+	return parseHealth(output)
+}
+
+// healthSubsystems names the components listed by racadm rollupstatus, the others keep their name
+var healthSubsystems = map[string]string{
+	"CPUs":           "CPU",
+	"Memory":         "Memory",
+	"Storage":        "Storage",
+	"Power Supplies": "PSU",
+	"Cooling":        "Fans",
+}
+
+// healthRanks orders the health of the components from the best to the worst
+var healthRanks = map[string]int{
+	"OK":       0,
+	"Warning":  1,
+	"Critical": 2,
+}
+
+// parseHealth reads the components listed by racadm rollupstatus, the absent ones are left out
+//
+// RollUp Status
+// CPUs                    = Healthy
+// Power Supplies          = Critical
+// Removable Flash Media   = Absent
+// Server Power Status     = ON
+func parseHealth(output string) (health HealthStatus, err error) {
+	health.Overall = "OK"
+	health.Subsystems = make(map[string]string)
+	for component, status := range parseRacadmFields(output) {
+		if component == "Server Power Status" {
+			continue
+		}
+
+		name, ok := healthSubsystems[component]
+		if !ok {
+			name = component
+		}
+
+		switch strings.ToLower(status) {
+		case "healthy", "ok":
+			status = "OK"
+		case "warning", "non-critical", "degraded":
+			status = "Warning"
+		case "critical", "error", "failed":
+			status = "Critical"
+		case "absent", "unknown", "n/a", "":
+			continue
+		default:
+			return health, fmt.Errorf("unknown health of %s: %s", component, status)
+		}
+
+		health.Subsystems[name] = status
+		if healthRanks[status] > healthRanks[health.Overall] {
+			health.Overall = status
+		}
+	}
+
+	if len(health.Subsystems) == 0 {
+		return health, fmt.Errorf("unable to find the health in: %s", output)
+	}
+
+	return health, err
+}
+
+// PowerSupplies returns the power supply slots of the server, a failed power supply is listed
+// with the status the bmc reports for it and an empty slot as not present
+func (i *IDrac8) PowerSupplies() (psus []PSU, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "PowerSupplies", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return psus, err
+	}
+
+	sensors, err := i.run("racadm getsensorinfo")
+	if err != nil {
+		return psus, &errors.CommandError{Cmd: "racadm getsensorinfo", Output: sensors, Err: err}
+	}
+
+	inventory, err := i.run("racadm hwinventory")
+	if err != nil {
+		return psus, &errors.CommandError{Cmd: "racadm hwinventory", Output: inventory, Err: err}
+	}
+
+	return parsePowerSupplies(sensors, inventory)
+}
+
+// parsePowerSupplies lists the slots of the POWER section of racadm getsensorinfo, the status and
+// the rating of the fitted ones come from their PSU.Slot component of racadm hwinventory
+//
+// Sensor Type : POWER
+// <Sensor Name>                   <Status>             <Type>
+// PS1 Status                      Present              AC
+// PS2 Status                      Absent               Unknown
+func parsePowerSupplies(sensors string, inventory string) (psus []PSU, err error) {
+	slots := make(map[string]map[string]string)
+	for _, component := range parseHwInventory(inventory) {
+		if slot := strings.TrimPrefix(component["InstanceID"], "PSU.Slot."); slot != component["InstanceID"] {
+			slots["PS"+slot] = component
+		}
+	}
+
+	for _, row := range sensorRows(sensors, "POWER") {
+		if len(row) < 2 || !strings.HasSuffix(row[0], " Status") {
+			continue
+		}
+
+		psu := PSU{Name: strings.TrimSuffix(row[0], " Status"), Status: row[1]}
+		psu.Present = !strings.EqualFold(row[1], "absent")
+
+		if component, ok := slots[psu.Name]; ok && psu.Present {
+			if status := component["PrimaryStatus"]; status != "" {
+				psu.Status = status
+			}
+
+			if rating, ok := component["Range1MaxInputPower"]; ok {
+				psu.InputWatts, err = hwQuantity(rating)
+				if err != nil {
+					return psus, fmt.Errorf("unexpected input power of %s: %s", psu.Name, rating)
+				}
+			}
+		}
+
+		psus = append(psus, psu)
+	}
+
+	if len(psus) == 0 {
+		return psus, errors.ErrFeatureUnavailable
+	}
+
+	return psus, err
+}
+
+// DIMMs returns the memory modules fitted in the server, the empty slots are left out
+func (i *IDrac8) DIMMs() (dimms []DIMM, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "DIMMs", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return dimms, err
+	}
+
+	output, err := i.run("racadm hwinventory")
+	if err != nil {
+		return dimms, fmt.Errorf("unable to read the hardware inventory: %s", output)
+	}
+
+	return parseDIMMs(output)
+}
+
+// parseDIMMs reads the DIMM.Socket components of racadm hwinventory, a slot without a size
+// or with a zero one is empty
+func parseDIMMs(output string) (dimms []DIMM, err error) {
+	for _, component := range parseHwInventory(output) {
+		if !strings.HasPrefix(component["InstanceID"], "DIMM.Socket.") {
+			continue
+		}
+
+		size, err := hwQuantity(component["Size"])
+		if err != nil || size == 0 {
+			continue
+		}
+
+		dimm := DIMM{
+			Slot:         strings.TrimPrefix(component["InstanceID"], "DIMM.Socket."),
+			SizeMB:       int(size),
+			Manufacturer: component["Manufacturer"],
+			Serial:       component["SerialNumber"],
+			Status:       component["PrimaryStatus"],
+		}
+
+		if speed, ok := component["Speed"]; ok {
+			value, err := hwQuantity(speed)
+			if err != nil {
+				return dimms, fmt.Errorf("unexpected speed of %s: %s", component["InstanceID"], speed)
+			}
+			dimm.SpeedMHz = int(value)
+		}
+
+		dimms = append(dimms, dimm)
+	}
+
+	return dimms, err
+}
+
+// CPUs returns the processors fitted in the server, one per populated socket
+func (i *IDrac8) CPUs() (cpus []CPU, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "CPUs", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return cpus, err
+	}
+
+	output, err := i.run("racadm hwinventory")
+	if err != nil {
+		return cpus, fmt.Errorf("unable to read the hardware inventory: %s", output)
+	}
+
+	return parseCPUs(output)
+}
+
+// parseCPUs reads the CPU.Socket components of racadm hwinventory, a socket without a model is empty
+func parseCPUs(output string) (cpus []CPU, err error) {
+	for _, component := range parseHwInventory(output) {
+		if !strings.HasPrefix(component["InstanceID"], "CPU.Socket.") || component["Model"] == "" {
+			continue
+		}
+
+		cpu := CPU{
+			Socket: strings.TrimPrefix(component["InstanceID"], "CPU.Socket."),
+			Model:  component["Model"],
+			Status: component["PrimaryStatus"],
+		}
+
+		if cores, ok := component["NumberOfProcessorCores"]; ok {
+			cpu.Cores, err = strconv.Atoi(cores)
+			if err != nil {
+				return cpus, fmt.Errorf("unexpected core count of %s: %s", component["InstanceID"], cores)
+			}
+		}
+
+		if speed, ok := component["CurrentClockSpeed"]; ok {
+			value, err := hwQuantity(speed)
+			if err != nil {
+				return cpus, fmt.Errorf("unexpected speed of %s: %s", component["InstanceID"], speed)
+			}
+			cpu.SpeedMHz = int(value)
+		}
+
+		cpus = append(cpus, cpu)
+	}
+
+	if len(cpus) == 0 {
+		return cpus, fmt.Errorf("unable to find any cpu in: %s", output)
+	}
+
+	return cpus, err
+}
+
+// Temperatures returns the readings of the temperature probes, the probes without a reading,
+// e.g. the ones of an empty cpu socket, are left out
+func (i *IDrac8) Temperatures() (sensors []Sensor, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "Temperatures", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return sensors, err
+	}
+
+	output, err := i.run("racadm getsensorinfo")
+	if err != nil {
+		return sensors, fmt.Errorf("unable to read the sensors: %s", output)
+	}
+
+	return parseTemperatures(output)
+}
+
+// Fans returns the speed of the fans, the slots without a fan fitted are left out
+func (i *IDrac8) Fans() (fans []Fan, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "Fans", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return fans, err
+	}
+
+	output, err := i.run("racadm getsensorinfo")
+	if err != nil {
+		return fans, fmt.Errorf("unable to read the sensors: %s", output)
+	}
+
+	return parseFans(output)
+}
+
+// sensorColumns splits the rows of racadm getsensorinfo, the columns are padded with
+// spaces and the sensor names have single spaces in them
+var sensorColumns = regexp.MustCompile(`\s{2,}`)
+
+// sensorRows returns the columns of the rows listed under the given sensor type of racadm getsensorinfo
+func sensorRows(output string, sensorType string) (rows [][]string) {
+	var section bool
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "Sensor Type") {
+			section = strings.HasSuffix(line, sensorType)
+			continue
+		}
+
+		if !section || line == "" || strings.HasPrefix(line, "<") {
+			continue
+		}
+
+		rows = append(rows, sensorColumns.Split(line, -1))
+	}
+
+	return rows
+}
+
+// parseTemperatures reads the TEMPERATURE section of racadm getsensorinfo
+//
+// Sensor Type : TEMPERATURE
+// <Sensor Name>                   <Status>    <Reading>   <lc>        <uc>        <lnc>[R/W]  <unc>[R/W]
+// System Board Inlet Temp         Ok          22C         -7C         47C         3C          42C
+// CPU2 Temp                       N/A         N/A         N/A         N/A         N/A         N/A
+func parseTemperatures(output string) (sensors []Sensor, err error) {
+	for _, row := range sensorRows(output, "TEMPERATURE") {
+		if len(row) < 3 || row[1] == "N/A" || row[2] == "N/A" {
+			continue
+		}
+
+		value := strings.TrimRight(row[2], "CFK ")
+		reading, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return sensors, fmt.Errorf("unexpected reading of %s: %s", row[0], row[2])
+		}
+
+		sensors = append(sensors, Sensor{
+			Name:    row[0],
+			Reading: reading,
+			Units:   strings.TrimSpace(row[2][len(value):]),
+			Status:  row[1],
+		})
+	}
+
+	return sensors, err
+}
+
+// parseFans reads the FAN section of racadm getsensorinfo
+//
+// Sensor Type : FAN
+// <Sensor Name>                   <Status>    <Reading>   <lc>        <uc>        <PST>
+// System Board Fan1 RPM           Ok          5880RPM     600RPM      NA          100
+// System Board Fan7 RPM           Absent      N/A         N/A         N/A         N/A
+func parseFans(output string) (fans []Fan, err error) {
+	for _, row := range sensorRows(output, "FAN") {
+		if len(row) < 3 || row[2] == "N/A" {
+			continue
+		}
+
+		switch strings.ToLower(row[1]) {
+		case "absent", "unknown", "n/a":
+			continue
+		}
+
+		rpm, err := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(row[2], "RPM")))
+		if err != nil {
+			return fans, fmt.Errorf("unexpected reading of %s: %s", row[0], row[2])
+		}
+
+		fans = append(fans, Fan{Name: row[0], RPM: rpm, Status: row[1]})
+	}
+
+	return fans, err
+}
+
+// PowerConsumption returns the power the server is drawing right now in watts, as read by
+// racadm getpminfo. Servers without a power monitoring sensor get errors.ErrFeatureUnavailable
+func (i *IDrac8) PowerConsumption() (watts float64, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "PowerConsumption", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return watts, err
+	}
+
+	output, err := i.run("racadm getpminfo")
+	if strings.Contains(strings.ToLower(output), "not supported") {
+		return watts, errors.ErrFeatureUnavailable
+	}
+	if err != nil {
+		return watts, fmt.Errorf("unable to read the power monitoring info: %s", output)
+	}
+
+	return parsePowerConsumption(output)
+}
+
+// parsePowerConsumption reads the present reading from racadm getpminfo
+//
+// Real-Time Power Statistics
+// System Input Power                  = 133 W     | 454 Btu/hr
+// System Peak Power                   = 298 W     | 1017 Btu/hr
+func parsePowerConsumption(output string) (watts float64, err error) {
+	if strings.Contains(strings.ToLower(output), "not supported") {
+		return watts, errors.ErrFeatureUnavailable
+	}
+
+	value, ok := parseRacadmFields(output)["System Input Power"]
+	if !ok {
+		return watts, errors.ErrFeatureUnavailable
+	}
+
+	reading := strings.Fields(value)
+	if len(reading) < 2 || reading[1] != "W" {
+		return watts, fmt.Errorf("unexpected power reading: %s", value)
+	}
+
+	return strconv.ParseFloat(reading[0], 64)
+}
+
+// GetPowerRestorePolicy returns the power state the machine goes to when the AC power comes back
+func (i *IDrac8) GetPowerRestorePolicy() (policy devices.PowerRestorePolicy, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "GetPowerRestorePolicy", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return policy, err
+	}
+
+	output, err := i.run("racadm get BIOS.SysSecurity.AcPwrRcvry")
+	if err != nil {
+		return policy, &errors.CommandError{Cmd: "racadm get BIOS.SysSecurity.AcPwrRcvry", Output: output, Err: err}
+	}
+
+	value := racadmValue(output)
+	for p, v := range biosRestorePolicies {
+		if strings.HasPrefix(value, v) {
+			return p, err
+		}
+	}
+
+	return policy, fmt.Errorf("unknown power restore policy: %s", output)
+}
+
+// GetBootMode returns the bios boot mode the machine is currently using
+func (i *IDrac8) GetBootMode() (mode devices.BootMode, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "GetBootMode", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return mode, err
+	}
+
+	output, err := i.run("racadm get BIOS.BiosBootSettings.BootMode")
+	if err != nil {
+		return mode, &errors.CommandError{Cmd: "racadm get BIOS.BiosBootSettings.BootMode", Output: output, Err: err}
+	}
+
+	// BootMode=Bios (Pending Value=Uefi)
+	value := racadmValue(output)
+	for m, v := range biosBootModes {
+		if strings.HasPrefix(value, v) {
+			return m, err
+		}
+	}
+
+	return mode, fmt.Errorf("unknown boot mode: %s", output)
+}
+
+// GetBootOrder returns the devices the machine tries to boot from in the boot mode it's currently
+// using, in order, as named by racadm, e.g. NIC.Integrated.1-1-1 or HardDisk.List.1-1
+func (i *IDrac8) GetBootOrder() (order []string, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "GetBootOrder", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return order, err
+	}
+
+	output, err := i.run("racadm get BIOS.BiosBootSettings")
+	if err != nil {
+		return order, fmt.Errorf("unable to read the boot order: %s", output)
+	}
+
+	return parseBootOrder(output)
+}
+
+// LicenseStatus returns the license installed in the idrac, the features like the virtual console or
+// the virtual media require an Enterprise one. An idrac without license is reported as Express
+func (i *IDrac8) LicenseStatus() (license LicenseInfo, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "LicenseStatus", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return license, err
+	}
+
+	output, err := i.run("racadm license view")
+	if err != nil {
+		return license, fmt.Errorf("unable to read the license: %s", output)
+	}
+
+	return parseLicense(output)
+}
+
+// licenseExpirationFormats are the layouts the expiration of the evaluation licenses is printed with
+var licenseExpirationFormats = []string{"2006-01-02T15:04:05", "2006-01-02", "Mon Jan 02 2006 15:04:05"}
+
+// parseLicense returns the license listed by racadm license view, the Enterprise one when several
+// are installed. The fields of each license, e.g. "License Description = iDRAC8 Enterprise License",
+// follow its "License #1" line and the ones of the device
+func parseLicense(output string) (license LicenseInfo, err error) {
+	if strings.Contains(strings.ToLower(output), "no license") {
+		return LicenseInfo{Type: "Express"}, err
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(output), "ERROR") {
+		return license, fmt.Errorf("unable to read the license: %s", output)
+	}
+
+	blocks := strings.Split(output, "License #")
+	if len(blocks) < 2 {
+		return LicenseInfo{Type: "Express"}, err
+	}
+
+	for n, block := range blocks[1:] {
+		fields := parseRacadmFields(block)
+
+		found := LicenseInfo{Type: fields["License Description"], Status: fields["Status"]}
+		for _, t := range []string{"Enterprise", "Express"} {
+			if strings.Contains(found.Type, t) {
+				found.Type = t
+			}
+		}
+
+		if expiration := fields["Expiration"]; expiration != "" && expiration != "Not Applicable" {
+			for _, format := range licenseExpirationFormats {
+				found.Expires, err = time.Parse(format, expiration)
+				if err == nil {
+					break
+				}
+			}
+			if err != nil {
+				return license, fmt.Errorf("unknown license expiration: %s", expiration)
+			}
+		}
+
+		if n == 0 || found.Type == "Enterprise" {
+			license = found
+		}
+	}
+
+	return license, err
+}
+
+// JobStatus returns the progress of the given job, e.g. the one returned by UpdateFirmware,
+// errors.ErrJobNotFound is returned when the job isn't in the job queue
+func (i *IDrac8) JobStatus(jobID string) (state JobState, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "JobStatus", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return state, err
+	}
+
+	// racadm fails when the job doesn't exist, which is told apart by the output
+	output, err := i.run(fmt.Sprintf("racadm jobqueue view -i %s", jobID))
+	if err != nil && output == "" {
+		return state, fmt.Errorf("unable to read the job %s: %v", jobID, err)
+	}
+
+	return parseJobState(jobID, output)
+}
+
+// parseJobState reads the job listed by racadm jobqueue view -i, the job being pending, e.g.
+// Scheduled or Downloading, is reported as Running
+func parseJobState(jobID string, output string) (state JobState, err error) {
+	job := parseRacadmFields(output)
+	if job["Job ID"] != jobID {
+		if jobNotFound(output) {
+			return state, errors.ErrJobNotFound
+		}
+		return state, fmt.Errorf("unable to read the job %s: %s", jobID, output)
+	}
+
+	state = JobState{
+		ID:      job["Job ID"],
+		Name:    job["Job Name"],
+		Status:  job["Status"],
+		Message: job["Message"],
+	}
+
+	switch strings.ToLower(state.Status) {
+	case "completed":
+		state.State = "Completed"
+	case "failed", "completed with errors", "cancelled", "canceled":
+		state.State = "Failed"
+	default:
+		state.State = "Running"
+	}
+
+	state.PercentComplete, _ = strconv.Atoi(job["Percent Complete"])
+	if state.State == "Completed" {
+		state.PercentComplete = 100
+	}
+
+	return state, err
+}
+
+// RecoveryCounters returns the watchdog (ASR) and NMI events posted to the bmc,
+// the iDrac doesn't keep dedicated counters so they are derived from the SEL
+func (i *IDrac8) RecoveryCounters() (stats devices.RecoveryStats, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "RecoveryCounters", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return stats, err
+	}
+
+	output, err := i.run("racadm getsel")
+	if err != nil {
+		return stats, &errors.CommandError{Cmd: "racadm getsel", Output: output, Err: err}
+	}
+
+	err = parseSEL(strings.NewReader(output), func(entry SELEntry) bool {
+		message := strings.ToLower(entry.Message)
+		if strings.Contains(message, "watchdog timer") && !strings.Contains(message, "expired") {
+			stats.ASRCount++
+			if entry.Timestamp.After(stats.LastASR) {
+				stats.LastASR = entry.Timestamp
+			}
+		} else if strings.Contains(message, "nmi") || strings.Contains(message, "non-maskable interrupt") {
+			stats.NMICount++
+		}
+		return true
+	})
+
+	return stats, err
+}
@@ -2,18 +2,23 @@ package idrac8
 
 import (
 	"bytes"
+	"context"
 	"encoding/xml"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/bmc-toolbox/bmclib/devices"
 	"github.com/bmc-toolbox/bmclib/errors"
 	"github.com/bmc-toolbox/bmclib/internal/httpclient"
 	"github.com/bmc-toolbox/bmclib/internal/sshclient"
+	"github.com/bmc-toolbox/bmclib/internal/tracing"
 	"github.com/bmc-toolbox/bmclib/providers/dell"
 	multierror "github.com/hashicorp/go-multierror"
+	"golang.org/x/crypto/ssh"
 
 	// this make possible to setup logging and properties at any stage
 	_ "github.com/bmc-toolbox/bmclib/logging"
@@ -112,19 +117,309 @@ func (i *IDrac8) loadHwData() (err error) {
 
 // sshLogin initiates the connection to a bmc device
 func (i *IDrac8) sshLogin() (err error) {
+	return i.sshLoginContext(context.Background())
+}
+
+// sshLoginContext initiates the connection to a bmc device, giving up when ctx is done.
+// The connection is reused across the actions as long as it's alive
+func (i *IDrac8) sshLoginContext(ctx context.Context) (err error) {
+	if i.runner != nil || i.dryRun {
+		return
+	}
+
+	if i.sshClient != nil {
+		if i.sshClient.Alive() {
+			return
+		}
+
+		log.WithFields(log.Fields{"step": "bmc connection", "vendor": dell.VendorID, "ip": i.ip}).Debug("ssh session is dead, reconnecting")
+		i.sshClient.Close()
+		i.sshClient = nil
+	}
+
+	if i.manualLogin {
+		return errors.ErrNotLoggedIn
+	}
+
+	return i.LoginWithContext(ctx)
+}
+
+// Login establishes the ssh session used by the actions, it's only required
+// to be called explicitly when the automatic login is disabled with SetAutoLogin
+func (i *IDrac8) Login() (err error) {
+	return i.LoginWithContext(context.Background())
+}
+
+// LoginWithContext works as Login giving up when ctx is done, the returned error wraps ctx.Err() then
+func (i *IDrac8) LoginWithContext(ctx context.Context) (err error) {
 	if i.sshClient != nil {
 		return
 	}
 
+	span := tracing.Start(tracing.Parent(ctx, i.traceCtx), dell.VendorID, "Login", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	log.WithFields(log.Fields{"step": "bmc connection", "vendor": dell.VendorID, "ip": i.ip}).Debug("connecting to bmc")
-	i.sshClient, err = sshclient.New(i.ip, i.username, i.password)
+	i.sshClient, err = sshclient.NewWithContext(ctx, i.ip, i.username, i.password, i.sshOptions)
 	if err != nil {
+		if clockSkewFailure(err) {
+			return i.clockSkewError(ctx, err)
+		}
 		return err
 	}
 
+	i.readOnly = false
+	i.readOnlyProbed = false
+
+	if i.maxClockSkew > 0 {
+		i.correctClock()
+	}
+
 	return err
 }
 
+// IsReachable tells whether the bmc answers over ssh, connecting when there's no session and running
+// a command that changes nothing. The error is dropped, it's meant to skip the dead bmcs quickly
+func (i *IDrac8) IsReachable() bool {
+	err := i.sshLogin()
+	if err != nil {
+		return false
+	}
+
+	_, err = i.run("racadm getractime -d")
+	return err == nil
+}
+
+// clockSkewError tells a login rejected because of a skewed bmc clock from a plain one, the clock
+// is read from the Date header of the web interface as it answers without login. The login failure
+// is returned as *errors.ClockSkewError when the clock is off by more than the allowed skew, as it is otherwise
+func (i *IDrac8) clockSkewError(ctx context.Context, loginErr error) error {
+	bmcTime, err := i.webClock(ctx)
+	if err != nil {
+		log.WithFields(log.Fields{"step": "bmc connection", "vendor": dell.VendorID, "ip": i.ip, "Error": err}).Debug("unable to read the bmc clock from the web interface")
+		return loginErr
+	}
+
+	maxSkew := i.maxClockSkew
+	if maxSkew <= 0 {
+		maxSkew = defaultMaxClockSkew
+	}
+
+	skew := time.Since(bmcTime)
+	if skew < 0 {
+		skew = -skew
+	}
+
+	if skew <= maxSkew {
+		return loginErr
+	}
+
+	return &errors.ClockSkewError{Host: i.ip, Skew: skew.Round(time.Second), Err: loginErr}
+}
+
+// webClock returns the time of the bmc clock given by the Date header of its web interface
+func (i *IDrac8) webClock(ctx context.Context) (t time.Time, err error) {
+	httpClient, err := httpclient.Build()
+	if err != nil {
+		return t, err
+	}
+
+	req, err := http.NewRequest("HEAD", fmt.Sprintf("https://%s/", i.ip), nil)
+	if err != nil {
+		return t, err
+	}
+
+	resp, err := httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return t, err
+	}
+	defer resp.Body.Close()
+
+	return http.ParseTime(resp.Header.Get("Date"))
+}
+
+// correctClock sets the bmc clock to the local time when it drifted more than the allowed skew,
+// spares powered off for long with a dead rtc battery come back with their clocks way off
+func (i *IDrac8) correctClock() {
+	bmcTime, err := i.BMCTime()
+	if err != nil {
+		log.WithFields(log.Fields{"step": "bmc connection", "vendor": dell.VendorID, "ip": i.ip, "Error": err}).Warn("unable to read the bmc clock")
+		return
+	}
+
+	skew := time.Since(bmcTime)
+	if skew < 0 {
+		skew = -skew
+	}
+
+	if skew <= i.maxClockSkew {
+		return
+	}
+
+	_, err = i.SetBMCTime(time.Now())
+	if err != nil {
+		log.WithFields(log.Fields{"step": "bmc connection", "vendor": dell.VendorID, "ip": i.ip, "skew": skew, "Error": err}).Warn("unable to correct the bmc clock")
+		return
+	}
+
+	log.WithFields(log.Fields{"step": "bmc connection", "vendor": dell.VendorID, "ip": i.ip, "skew": skew}).Debug("bmc clock corrected")
+	i.clockCorrection = skew
+}
+
+// SetClockCorrection enables the correction of the bmc clock at login whenever it differs from the
+// local time by more than maxSkew, zero disables it. ClockCorrection reports the applied correction.
+// A rejected login is returned as *errors.ClockSkewError past maxSkew, five minutes when disabled
+func (i *IDrac8) SetClockCorrection(maxSkew time.Duration) {
+	i.maxClockSkew = maxSkew
+}
+
+// ClockCorrection returns how much the bmc clock was off when it got corrected at login, zero otherwise
+func (i *IDrac8) ClockCorrection() time.Duration {
+	return i.clockCorrection
+}
+
+// probeReadOnly writes back the current timezone, bmcs in maintenance or
+// recovery mode keep answering reads but reject every write. It's a write
+// itself, so it only runs before the first action changing the device
+func (i *IDrac8) probeReadOnly() bool {
+	output, err := i.run("racadm get iDRAC.Time.Timezone")
+	timezone := racadmValue(output)
+	if err != nil || timezone == "" {
+		log.WithFields(log.Fields{"step": "bmc connection", "vendor": dell.VendorID, "ip": i.ip}).Debug("unable to probe if the bmc is read-only")
+		return false
+	}
+
+	output, err = i.run(fmt.Sprintf("racadm set iDRAC.Time.Timezone %s", timezone))
+	if err != nil || !strings.Contains(output, "successful") {
+		log.WithFields(log.Fields{"step": "bmc connection", "vendor": dell.VendorID, "ip": i.ip, "output": output}).Warn("bmc is rejecting writes, it's probably in maintenance mode")
+		return true
+	}
+
+	return false
+}
+
+// sshLoginRW initiates the connection for the actions changing the device,
+// failing when the bmc is read-only. The first call on a session probes it
+func (i *IDrac8) sshLoginRW() (err error) {
+	return i.sshLoginRWContext(context.Background())
+}
+
+// sshLoginRWContext works as sshLoginRW giving up when ctx is done
+func (i *IDrac8) sshLoginRWContext(ctx context.Context) (err error) {
+	err = i.sshLoginContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !i.readOnlyProbed && i.runner == nil && !i.dryRun {
+		i.readOnly = i.probeReadOnly()
+		i.readOnlyProbed = true
+	}
+
+	if i.readOnly {
+		return &errors.BMCReadOnlyError{Host: i.ip}
+	}
+
+	return err
+}
+
+// SetRunner sends the commands to r instead of the ssh client, no ssh session is established then
+func (i *IDrac8) SetRunner(r Runner) {
+	i.runner = r
+}
+
+// SetDryRun makes the actions log the commands they'd run instead of sending them to the bmc, each
+// command is answered with itself and reported successful. No ssh session is established then, the
+// lookups the actions depend on are skipped, e.g. the user slot, and the reads should be done with
+// the dry run disabled
+func (i *IDrac8) SetDryRun(enable bool) {
+	i.dryRun = enable
+}
+
+// SetAutoLogin defines whether the actions should establish the ssh session on demand,
+// when disabled the caller owns the session lifecycle via Login and Close
+func (i *IDrac8) SetAutoLogin(enable bool) {
+	i.manualLogin = !enable
+}
+
+// SetOutputEncoding defines how the ssh command output is normalized before being parsed,
+// use devices.EncodingUTF8 or devices.EncodingLatin1 when the bmc prints non utf-8 data
+func (i *IDrac8) SetOutputEncoding(encoding string) {
+	i.sshOptions.Encoding = encoding
+}
+
+// SetMaxOutputSize overrides the maximum number of bytes read from the output of a ssh command,
+// the command is aborted with a *errors.OutputTooLargeError past it. It's a few MB by default
+func (i *IDrac8) SetMaxOutputSize(size int) {
+	i.sshOptions.MaxOutputSize = size
+}
+
+// SetSSHPort overrides the port used to connect over ssh, devices.SSHPort by default
+func (i *IDrac8) SetSSHPort(port int) {
+	i.sshOptions.Port = port
+}
+
+// SetSSHTimeout overrides how long connecting over ssh may take, sshclient.DefaultTimeout by default.
+// Past it the login fails with an error wrapping errors.ErrConnectionTimeout
+func (i *IDrac8) SetSSHTimeout(timeout time.Duration) {
+	i.sshOptions.Timeout = timeout
+}
+
+// SetSSHKey makes the ssh sessions established afterwards authenticate with the given key,
+// the password is only tried when the bmc rejects it
+func (i *IDrac8) SetSSHKey(signer ssh.Signer) {
+	i.sshOptions.Signer = signer
+}
+
+// SetHostKeyCallback makes the ssh sessions established afterwards verify the host key of the bmc
+// with the given callback, e.g. one of golang.org/x/crypto/ssh/knownhosts. Any key is accepted by default
+func (i *IDrac8) SetHostKeyCallback(callback ssh.HostKeyCallback) {
+	i.sshOptions.HostKeyCallback = callback
+}
+
+// SetLogger makes the ssh sessions established afterwards pass every command run and its output,
+// truncated and with the secrets masked, to the given logger. Nothing is logged by default
+func (i *IDrac8) SetLogger(logger devices.Logger) {
+	i.sshOptions.Logger = logger
+}
+
+// SetSSHAlgorithms overrides the ciphers, key exchanges and macs offered during the ssh handshake,
+// old firmwares require devices.LegacySSHCiphers, devices.LegacySSHKeyExchanges and devices.LegacySSHMACs
+func (i *IDrac8) SetSSHAlgorithms(ciphers []string, kex []string, macs []string) {
+	i.sshOptions.Ciphers = ciphers
+	i.sshOptions.KeyExchanges = kex
+	i.sshOptions.MACs = macs
+}
+
+// SetSuccessMatcher overrides how the output of the given action, named after its method, is
+// recognized as successful. The command exiting with an error is always a failure. By default the
+// actions look for "successful", PowerCycleBmc for "initiated successfully" and DeleteJob for RAC1032,
+// some firmware revisions print "completed successfully" or "Operation successful" instead. The bios
+// job scheduled by SetBootMode and SetPowerRestorePolicy is matched as the BiosJob action.
+// e.g. SetSuccessMatcher("PowerOff", regexp.MustCompile(`(?i)success`).MatchString)
+func (i *IDrac8) SetSuccessMatcher(action string, matcher devices.SuccessMatcher) {
+	if i.successMatchers == nil {
+		i.successMatchers = make(map[string]devices.SuccessMatcher)
+	}
+	i.successMatchers[action] = matcher
+}
+
+// LastTimings returns how long the ssh connection took to dial and authenticate and how long
+// the last command took to run, Reused tells whether the command ran over an existing connection
+func (i *IDrac8) LastTimings() (timings devices.Timings) {
+	if i.sshClient == nil {
+		return timings
+	}
+
+	return i.sshClient.Timings()
+}
+
+// SetTraceContext defines the context the OpenTelemetry spans of the actions are attached to,
+// the *WithContext methods attach them to their ctx instead when it carries a span
+func (i *IDrac8) SetTraceContext(ctx context.Context) {
+	i.traceCtx = ctx
+}
+
 // Close closes the connection properly
 func (i *IDrac8) Close() (err error) {
 	if i.httpClient != nil {
@@ -142,7 +437,36 @@ func (i *IDrac8) Close() (err error) {
 		if e != nil {
 			err = multierror.Append(e, err)
 		}
+		i.sshClient = nil
 	}
 
 	return err
 }
+
+// closeSSH drops the ssh session once the bmc is known to have closed it, the next action connects again
+func (i *IDrac8) closeSSH() {
+	if i.sshClient != nil {
+		i.sshClient.Close()
+		i.sshClient = nil
+	}
+}
+
+// Refresh drops the sessions and everything learnt about the iDRAC and logs in again, the read-only
+// and clock probes run again with the next ssh session. It should be called once the iDRAC is back
+// online after its firmware was updated or it was reset, otherwise the inventory read at login is kept
+func (i *IDrac8) Refresh() (err error) {
+	// the sessions most likely died with the old firmware, failing to logout is expected
+	e := i.Close()
+	if e != nil {
+		log.WithFields(log.Fields{"step": "bmc connection", "vendor": dell.VendorID, "ip": i.ip, "error": e}).Debug("unable to close the previous sessions")
+	}
+	i.httpClient = nil
+	i.st1 = ""
+	i.st2 = ""
+	i.iDracInventory = nil
+	i.readOnly = false
+	i.readOnlyProbed = false
+	i.clockCorrection = 0
+
+	return i.httpLogin()
+}
@@ -3,16 +3,64 @@ package idrac9
 import (
 	"fmt"
 	"strings"
+
+	"github.com/bmc-toolbox/bmclib/devices"
+	"github.com/bmc-toolbox/bmclib/errors"
+	"github.com/bmc-toolbox/bmclib/internal/tracing"
+	"github.com/bmc-toolbox/bmclib/providers/dell"
 )
 
 // PowerCycle reboots the machine via bmc
 func (i *IDrac9) PowerCycle() (status bool, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "PowerCycle", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	return i.route("PowerCycle", map[string]func() (bool, error){
+		devices.TransportRedfish: func() (bool, error) { return i.redfishReset("ForceRestart") },
+		devices.TransportSSH:     i.sshPowerCycle,
+	})
+}
+
+// sshPowerCycle runs racadm serveraction hardreset over ssh
+func (i *IDrac9) sshPowerCycle() (status bool, err error) {
+	err = i.sshLogin()
+	if err != nil {
+		return status, err
+	}
+
+	output, err := i.run("racadm serveraction hardreset")
+	if err != nil {
+		return false, fmt.Errorf(output)
+	}
+	if strings.Contains(output, "successful") {
+		return true, err
+	}
+
+	return status, fmt.Errorf(output)
+}
+
+// PowerCycleWith reboots the machine via bmc using the given reset type,
+// a cold reset power cycles the server while a warm reset is the same as PowerCycle
+func (i *IDrac9) PowerCycleWith(resetType devices.ResetType) (status bool, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "PowerCycleWith", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	var command string
+	switch resetType {
+	case devices.ResetWarm:
+		command = "racadm serveraction hardreset"
+	case devices.ResetCold:
+		command = "racadm serveraction powercycle"
+	default:
+		return status, errors.ErrFeatureUnavailable
+	}
+
 	err = i.sshLogin()
 	if err != nil {
 		return status, err
 	}
 
-	output, err := i.sshClient.Run("racadm serveraction hardreset")
+	output, err := i.run(command)
 	if err != nil {
 		return false, fmt.Errorf(output)
 	}
@@ -25,12 +73,15 @@ func (i *IDrac9) PowerCycle() (status bool, err error) {
 
 // PowerCycleBmc reboots the bmc we are connected to
 func (i *IDrac9) PowerCycleBmc() (status bool, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "PowerCycleBmc", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.sshLogin()
 	if err != nil {
 		return status, err
 	}
 
-	output, err := i.sshClient.Run("racadm racreset hard")
+	output, err := i.run("racadm racreset hard")
 	if err != nil {
 		return false, fmt.Errorf(output)
 	}
@@ -43,12 +94,23 @@ func (i *IDrac9) PowerCycleBmc() (status bool, err error) {
 
 // PowerOn power on the machine via bmc
 func (i *IDrac9) PowerOn() (status bool, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "PowerOn", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	return i.route("PowerOn", map[string]func() (bool, error){
+		devices.TransportRedfish: func() (bool, error) { return i.redfishReset("On") },
+		devices.TransportSSH:     i.sshPowerOn,
+	})
+}
+
+// sshPowerOn runs racadm serveraction powerup over ssh
+func (i *IDrac9) sshPowerOn() (status bool, err error) {
 	err = i.sshLogin()
 	if err != nil {
 		return status, err
 	}
 
-	output, err := i.sshClient.Run("racadm serveraction powerup")
+	output, err := i.run("racadm serveraction powerup")
 	if err != nil {
 		return false, fmt.Errorf(output)
 	}
@@ -62,12 +124,51 @@ func (i *IDrac9) PowerOn() (status bool, err error) {
 
 // PowerOff power off the machine via bmc
 func (i *IDrac9) PowerOff() (status bool, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "PowerOff", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	return i.route("PowerOff", map[string]func() (bool, error){
+		devices.TransportRedfish: func() (bool, error) { return i.redfishReset("ForceOff") },
+		devices.TransportSSH:     i.sshPowerOff,
+	})
+}
+
+// sshPowerOff runs racadm serveraction powerdown over ssh
+func (i *IDrac9) sshPowerOff() (status bool, err error) {
+	err = i.sshLogin()
+	if err != nil {
+		return status, err
+	}
+
+	output, err := i.run("racadm serveraction powerdown")
+	if err != nil {
+		return false, fmt.Errorf(output)
+	}
+
+	if strings.Contains(output, "successful") {
+		return true, err
+	}
+
+	return status, fmt.Errorf(output)
+}
+
+// PressPowerButton emulates a press of the power button, a held press forces the machine
+// off while a momentary press signals the operating system to shutdown
+func (i *IDrac9) PressPowerButton(hold bool) (status bool, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "PressPowerButton", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.sshLogin()
 	if err != nil {
 		return status, err
 	}
 
-	output, err := i.sshClient.Run("racadm serveraction powerdown")
+	command := "racadm serveraction graceshutdown"
+	if hold {
+		command = "racadm serveraction powerdown"
+	}
+
+	output, err := i.run(command)
 	if err != nil {
 		return false, fmt.Errorf(output)
 	}
@@ -79,19 +180,23 @@ func (i *IDrac9) PowerOff() (status bool, err error) {
 	return status, fmt.Errorf(output)
 }
 
-// PxeOnce makes the machine to boot via pxe once
+// PxeOnce makes the machine to boot via pxe once, the iDRAC9 firmwares deprecate the racadm config
+// groups used by iDRAC8 so the iDRAC.ServerBoot attributes are set instead
 func (i *IDrac9) PxeOnce() (status bool, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "PxeOnce", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.sshLogin()
 	if err != nil {
 		return status, err
 	}
 
-	output, err := i.sshClient.Run("racadm config -g cfgServerInfo -o cfgServerBootOnce 1")
+	output, err := i.sshClient.Run("racadm set iDRAC.ServerBoot.BootOnce Enabled")
 	if err != nil {
 		return false, fmt.Errorf(output)
 	}
 	if strings.Contains(output, "successful") {
-		output, err = i.sshClient.Run("racadm config -g cfgServerInfo -o cfgServerFirstBootDevice PXE")
+		output, err = i.sshClient.Run("racadm set iDRAC.ServerBoot.FirstBootDevice PXE")
 		if err != nil {
 			return false, fmt.Errorf(output)
 		}
@@ -105,6 +210,9 @@ func (i *IDrac9) PxeOnce() (status bool, err error) {
 
 // IsOn tells if a machine is currently powered on
 func (i *IDrac9) IsOn() (status bool, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "IsOn", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.sshLogin()
 	if err != nil {
 		return status, err
@@ -120,3 +228,32 @@ func (i *IDrac9) IsOn() (status bool, err error) {
 
 	return status, fmt.Errorf(output)
 }
+
+// CommitPending creates the job applying the bios changes staged by racadm set, unlike iDRAC8 the
+// changes are not applied until the job exists. This allows to batch multiple changes and commit
+// them once, the job runs on the next reboot
+func (i *IDrac9) CommitPending() (jobID string, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "CommitPending", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return jobID, err
+	}
+
+	output, err := i.sshClient.Run("racadm jobqueue create BIOS.Setup.1-1")
+	if err != nil {
+		return jobID, fmt.Errorf("unable to create the commit job: %s", output)
+	}
+
+	jobID = jobIDPattern.FindString(output)
+	if jobID == "" {
+		return jobID, fmt.Errorf("unable to create the commit job: %s", output)
+	}
+
+	if i.commitTimeout > 0 {
+		err = i.waitForJob(jobID, i.commitTimeout)
+	}
+
+	return jobID, err
+}
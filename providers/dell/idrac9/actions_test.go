@@ -5,11 +5,15 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
+	goerrors "errors"
 	"fmt"
 	"log"
 	"net"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/bmc-toolbox/bmclib/errors"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -23,29 +27,40 @@ var (
 		"racadm racreset hard": []byte(`RAC reset operation initiated successfully. It may take a few
 			minutes for the RAC to come online again.
 		   `),
-		"racadm serveraction powerup":     []byte(`Server power operation successful`),
-		"racadm serveraction powerdown":   []byte(`Server power operation successful`),
-		"racadm serveraction powerstatus": []byte(`Server power status: ON`),
-		"racadm config -g cfgServerInfo -o cfgServerBootOnce 1": []byte(`Object value modified successfully
-
-
-			RAC1169: The RACADM "config" command will be deprecated in a
-			future version of iDRAC firmware. Run the RACADM 
-			"racadm set" command to configure the iDRAC configuration parameters.
-			For more information on the set command, run the RACADM command
-			"racadm help set".
-			
+		"racadm serveraction powerup":   []byte(`Server power operation successful`),
+		"racadm serveraction powerdown": []byte(`Server power operation successful`),
+		"racadm getsysinfo": []byte(`RAC Information:
+			RAC Date/Time           = Tue Feb 13 2018 10:02:48
+			Firmware Version        = 2.50.33.50
+			Firmware Build          = 03
+
+			System Information:
+			System Model            = PowerEdge M630
+			System Revision         = I
+			System BIOS Version     = 2.4.2
+			Service Tag             = 65kt7j2
+			Express Svc Code        = 13270494187
+			Host Name               = machine.example.com
+			Power Status            = ON
 			`),
-		"racadm config -g cfgServerInfo -o cfgServerFirstBootDevice PXE": []byte(`Object value modified successfully
-
-
-			RAC1169: The RACADM "config" command will be deprecated in a
-			future version of iDRAC firmware. Run the RACADM 
-			"racadm set" command to configure the iDRAC configuration parameters.
-			For more information on the set command, run the RACADM command
-			"racadm help set".
-			
+		"racadm serveraction powerstatus":          []byte(`Server power status: ON`),
+		"racadm set iDRAC.Users.3.Password secret": []byte(`Object value modified successfully`),
+		"racadm jobqueue create BIOS.Setup.1-1": []byte(`RAC1024: Successfully scheduled a job.
+			Verify the job status using "racadm jobqueue view -i JID_xxxxx" command.
+			Commit JID = JID_372366001531
 			`),
+		"racadm jobqueue view -i JID_372366001531": []byte(`---------------------------- JOB -------------------------
+			[Job ID=JID_372366001531]
+			Job Name=Configure: BIOS.Setup.1-1
+			Status=Scheduled
+			Start Time=[Now]
+			Expiration Time=[Not Applicable]
+			Message=[JCP001: Task successfully scheduled.]
+			Percent Complete=[0]
+			----------------------------------------------------------
+			`),
+		"racadm set iDRAC.ServerBoot.BootOnce Enabled":    []byte(`Object value modified successfully`),
+		"racadm set iDRAC.ServerBoot.FirstBootDevice PXE": []byte(`Object value modified successfully`),
 	}
 )
 
@@ -307,3 +322,84 @@ func TestIDracIsOn(t *testing.T) {
 		t.Errorf("Expected answer %v: found %v", expectedAnswer, answer)
 	}
 }
+
+func TestIDracCommitPending(t *testing.T) {
+	expectedAnswer := "JID_372366001531"
+
+	bmc, err := setupSSH()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+	defer tearDownSSH()
+
+	bmc.SetCommitTimeout(time.Minute)
+
+	answer, err := bmc.CommitPending()
+	if err != nil {
+		t.Fatalf("Found errors calling bmc.CommitPending %v", err)
+	}
+
+	if answer != expectedAnswer {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, answer)
+	}
+}
+
+func TestIDracServiceTag(t *testing.T) {
+	expectedAnswer := "65KT7J2"
+
+	bmc, err := setupSSH()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+	defer tearDownSSH()
+
+	answer, err := bmc.ServiceTag()
+	if err != nil {
+		t.Fatalf("Found errors calling bmc.ServiceTag %v", err)
+	}
+
+	if answer != expectedAnswer {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, answer)
+	}
+}
+
+func TestIDracMaxOutputSize(t *testing.T) {
+	expectedAnswer := "RAC Information:"
+
+	bmc, err := setupSSH()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+	defer tearDownSSH()
+
+	bmc.SetMaxOutputSize(len(expectedAnswer))
+
+	err = bmc.Login()
+	if err != nil {
+		t.Fatalf("Found errors during the ssh login %v", err)
+	}
+
+	_, err = bmc.sshClient.Run("racadm getsysinfo")
+	tooLarge, ok := err.(*errors.OutputTooLargeError)
+	if !ok {
+		t.Fatalf("Expected an OutputTooLargeError running racadm getsysinfo: found %v", err)
+	}
+
+	if tooLarge.Output != expectedAnswer {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, tooLarge.Output)
+	}
+
+	if !goerrors.Is(err, errors.ErrOutputTruncated) {
+		t.Errorf("Expected the error to wrap %v: found %v", errors.ErrOutputTruncated, err)
+	}
+
+	_, err = bmc.sshClient.Run("racadm set iDRAC.Users.3.Password secret")
+	tooLarge, ok = err.(*errors.OutputTooLargeError)
+	if !ok {
+		t.Fatalf("Expected an OutputTooLargeError setting the password: found %v", err)
+	}
+
+	if strings.Contains(tooLarge.Error(), "secret") {
+		t.Errorf("Expected the password to be redacted: found %v", tooLarge.Error())
+	}
+}
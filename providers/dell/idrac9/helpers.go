@@ -1,13 +1,29 @@
 package idrac9
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/bmc-toolbox/bmclib/cfgresources"
+	bmclibErrors "github.com/bmc-toolbox/bmclib/errors"
+	"github.com/bmc-toolbox/bmclib/internal/poll"
 )
 
+// commands known to ask "Are you sure? (y/n)" before doing anything, depending on the
+// firmware the power and reset actions may prompt as well
+var confirmationPrompts = []string{
+	"racadm racreset",
+	"racadm serveraction",
+}
+
+// jobIDPattern matches the job ids printed by racadm, e.g. Commit JID = JID_372366001531
+var jobIDPattern = regexp.MustCompile(`JID_[0-9]+`)
+
 // Return bool value if the role is valid.
 func isRoleValid(role string) bool {
 
@@ -317,3 +333,55 @@ func (i *IDrac9) putIpmiOverLan(ipmiOverLan IpmiOverLan) (err error) {
 
 	return err
 }
+
+// run executes the command over ssh, answering the confirmation prompt of the commands that print one
+func (i *IDrac9) run(command string) (output string, err error) {
+	for _, prompt := range confirmationPrompts {
+		if strings.HasPrefix(command, prompt) {
+			return i.sshClient.RunWithConfirmation(command)
+		}
+	}
+
+	return i.sshClient.Run(command)
+}
+
+// waitForJob polls the job queue until the given job is scheduled or completed
+func (i *IDrac9) waitForJob(jobID string, timeout time.Duration) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var status string
+	err = poll.Until(ctx, i.pollOptions, func() (done bool, err error) {
+		output, err := i.sshClient.Run(fmt.Sprintf("racadm jobqueue view -i %s", jobID))
+		if err != nil {
+			return false, fmt.Errorf("unable to read the job %s: %s", jobID, output)
+		}
+
+		status = racadmField(output, "Status")
+		switch status {
+		case "Scheduled", "Completed":
+			return true, nil
+		case "Failed", "Completed with Errors":
+			return false, fmt.Errorf("job %s failed: %s", jobID, output)
+		}
+
+		return false, nil
+	})
+	if err == context.DeadlineExceeded || err == bmclibErrors.ErrMaxAttemptsReached {
+		return fmt.Errorf("timeout waiting for the job %s, last status: %s", jobID, status)
+	}
+
+	return err
+}
+
+// racadmField returns the value of the given key=value line printed by racadm
+func racadmField(output string, key string) (value string) {
+	for _, line := range strings.Split(output, "\n") {
+		data := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(data) == 2 && strings.TrimSpace(data[0]) == key {
+			return strings.TrimSpace(data[1])
+		}
+	}
+
+	return value
+}
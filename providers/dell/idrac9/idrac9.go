@@ -2,6 +2,7 @@ package idrac9
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
@@ -10,13 +11,16 @@ import (
 	"net/http/httputil"
 	"strconv"
 	"strings"
+	"time"
 
 	multierror "github.com/hashicorp/go-multierror"
 
 	"github.com/bmc-toolbox/bmclib/devices"
 	"github.com/bmc-toolbox/bmclib/errors"
+	"github.com/bmc-toolbox/bmclib/internal/helper"
 	"github.com/bmc-toolbox/bmclib/internal/httpclient"
 	"github.com/bmc-toolbox/bmclib/internal/sshclient"
+	"github.com/bmc-toolbox/bmclib/internal/tracing"
 	"github.com/bmc-toolbox/bmclib/providers/dell"
 
 	// this make possible to setup logging and properties at any stage
@@ -37,9 +41,23 @@ type IDrac9 struct {
 	xsrfToken      string
 	httpClient     *http.Client
 	sshClient      *sshclient.SSHClient
+	sshOptions     sshclient.Options
+	manualLogin    bool
+	traceCtx       context.Context
+	tokenProvider  devices.TokenProvider
+	redfishToken   string
+	commitTimeout  time.Duration
+	pollOptions    devices.PollOptions
+	transports     map[string][]string
+	lastTransport  map[string]string
 	iDracInventory *dell.IDracInventory
 }
 
+var (
+	_ devices.PowerController = (*IDrac9)(nil)
+	_ devices.Inventory       = (*IDrac9)(nil)
+)
+
 // New returns a new IDrac9 ready to be used
 func New(ip string, username string, password string) (iDrac *IDrac9, err error) {
 	return &IDrac9{ip: ip, username: username, password: password}, err
@@ -47,6 +65,9 @@ func New(ip string, username string, password string) (iDrac *IDrac9, err error)
 
 // CheckCredentials verify whether the credentials are valid or not
 func (i *IDrac9) CheckCredentials() (err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "CheckCredentials", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return err
@@ -207,6 +228,9 @@ func (i *IDrac9) delete_(endpoint string) (statusCode int, payload []byte, err e
 
 // Nics returns all found Nics in the device
 func (i *IDrac9) Nics() (nics []*devices.Nic, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "Nics", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return nics, err
@@ -260,6 +284,9 @@ func (i *IDrac9) Nics() (nics []*devices.Nic, err error) {
 
 // Serial returns the device serial
 func (i *IDrac9) Serial() (serial string, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "Serial", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return serial, err
@@ -279,6 +306,9 @@ func (i *IDrac9) Serial() (serial string, err error) {
 
 // Status returns health string status from the bmc
 func (i *IDrac9) Status() (status string, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "Status", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return status, err
@@ -312,6 +342,9 @@ func (i *IDrac9) Status() (status string, err error) {
 
 // PowerKw returns the current power usage in Kw
 func (i *IDrac9) PowerKw() (power float64, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "PowerKw", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return power, err
@@ -335,6 +368,9 @@ func (i *IDrac9) PowerKw() (power float64, err error) {
 
 // PowerState returns the current power state of the machine
 func (i *IDrac9) PowerState() (state string, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "PowerState", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return state, err
@@ -354,6 +390,9 @@ func (i *IDrac9) PowerState() (state string, err error) {
 
 // BiosVersion returns the current version of the bios
 func (i *IDrac9) BiosVersion() (version string, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "BiosVersion", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return version, err
@@ -374,6 +413,9 @@ func (i *IDrac9) BiosVersion() (version string, err error) {
 
 // Name returns the name of this server from the bmc point of view
 func (i *IDrac9) Name() (name string, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "Name", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return name, err
@@ -394,6 +436,9 @@ func (i *IDrac9) Name() (name string, err error) {
 
 // BmcVersion returns the version of the bmc we are running
 func (i *IDrac9) BmcVersion() (bmcVersion string, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "BmcVersion", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return bmcVersion, err
@@ -413,6 +458,9 @@ func (i *IDrac9) BmcVersion() (bmcVersion string, err error) {
 
 // Model returns the device model
 func (i *IDrac9) Model() (model string, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "Model", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return model, err
@@ -437,6 +485,9 @@ func (i *IDrac9) BmcType() (bmcType string) {
 
 // License returns the bmc license information
 func (i *IDrac9) License() (name string, licType string, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "License", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return name, licType, err
@@ -467,6 +518,9 @@ func (i *IDrac9) License() (name string, licType string, err error) {
 
 // Memory return the total amount of memory of the server
 func (i *IDrac9) Memory() (mem int, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "Memory", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return mem, err
@@ -490,6 +544,9 @@ func (i *IDrac9) Memory() (mem int, err error) {
 
 // TempC returns the current temperature of the machine
 func (i *IDrac9) TempC() (temp int, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "TempC", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return temp, err
@@ -517,6 +574,9 @@ func (i *IDrac9) TempC() (temp int, err error) {
 
 // CPU return the cpu, cores and hyperthreads the server
 func (i *IDrac9) CPU() (cpu string, cpuCount int, coreCount int, hyperthreadCount int, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "CPU", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return cpu, cpuCount, coreCount, hyperthreadCount, err
@@ -549,6 +609,9 @@ func (i *IDrac9) CPU() (cpu string, cpuCount int, coreCount int, hyperthreadCoun
 
 // IsBlade returns if the current hardware is a blade or not
 func (i *IDrac9) IsBlade() (isBlade bool, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "IsBlade", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return isBlade, err
@@ -568,6 +631,9 @@ func (i *IDrac9) IsBlade() (isBlade bool, err error) {
 
 // Psus returns a list of psus installed on the device
 func (i *IDrac9) Psus() (psus []*devices.Psu, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "Psus", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return psus, err
@@ -616,8 +682,16 @@ func (i *IDrac9) Vendor() (vendor string) {
 	return dell.VendorID
 }
 
+// SupportedActions returns the names of the action methods implemented by this provider
+func (i *IDrac9) SupportedActions() (actions []string) {
+	return helper.SupportedActions(i)
+}
+
 // ServerSnapshot do best effort to populate the server data and returns a blade or discrete
 func (i *IDrac9) ServerSnapshot() (server interface{}, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "ServerSnapshot", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return server, err
@@ -760,6 +834,9 @@ func (i *IDrac9) ServerSnapshot() (server interface{}, err error) {
 
 // Disks returns a list of disks installed on the device
 func (i *IDrac9) Disks() (disks []*devices.Disk, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "Disks", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return disks, err
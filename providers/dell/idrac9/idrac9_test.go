@@ -1,8 +1,10 @@
 package idrac9
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -4382,3 +4384,110 @@ func TestUpdateCredentials(t *testing.T) {
 
 	tearDown()
 }
+
+func TestIDracTokenProvider(t *testing.T) {
+	expectedAnswer := []string{"expired", "fresh"}
+
+	bmc, err := setup()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+	defer tearDown()
+
+	mux.HandleFunc("/redfish/v1/Systems/System.Embedded.1/Bios", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Auth-Token") != "fresh" {
+			w.WriteHeader(401)
+			return
+		}
+		w.Write([]byte(`{"Attributes":{"PxeDev1EnDis":"Enabled"}}`))
+	})
+
+	err = bmc.httpLogin()
+	if err != nil {
+		t.Fatalf("Found errors during the login %v", err)
+	}
+
+	answer := []string{}
+	bmc.SetTokenProvider(func() (string, error) {
+		answer = append(answer, expectedAnswer[len(answer)])
+		return answer[len(answer)-1], nil
+	})
+
+	settings, err := bmc.getBiosSettings()
+	if err != nil {
+		t.Fatalf("Found errors calling bmc.getBiosSettings %v", err)
+	}
+
+	if settings.PxeDev1EnDis != "Enabled" || !reflect.DeepEqual(answer, expectedAnswer) {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, answer)
+	}
+}
+
+func TestIDracTransportFallback(t *testing.T) {
+	expectedAnswer := map[string]string{"PowerOn": devices.TransportRedfish, "PowerCycle": devices.TransportSSH}
+
+	bmc, err := setup()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+	defer tearDown()
+
+	mux.HandleFunc("/redfish/v1/Systems/System.Embedded.1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Actions":{"#ComputerSystem.Reset":{"target":"/redfish/v1/Systems/System.Embedded.1/Actions/ComputerSystem.Reset","ResetType@Redfish.AllowableValues":["On","ForceOff"]}}}`))
+	})
+	mux.HandleFunc("/redfish/v1/Systems/System.Embedded.1/Actions/ComputerSystem.Reset", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(204)
+	})
+
+	sshBmc, err := setupSSH()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+	defer tearDownSSH()
+
+	err = sshBmc.Login()
+	if err != nil {
+		t.Fatalf("Found errors during the ssh login %v", err)
+	}
+	bmc.sshClient = sshBmc.sshClient
+
+	answer := map[string]string{}
+	for action, call := range map[string]func() (bool, error){"PowerOn": bmc.PowerOn, "PowerCycle": bmc.PowerCycle} {
+		status, err := call()
+		if err != nil || !status {
+			t.Fatalf("Found errors calling bmc.%s %v", action, err)
+		}
+		answer[action] = bmc.LastTransport(action)
+	}
+
+	if !reflect.DeepEqual(answer, expectedAnswer) {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, answer)
+	}
+}
+
+func TestIDracTransportFailure(t *testing.T) {
+	bmc, err := New("127.0.0.1", "super", "test")
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+
+	_, err = bmc.route("PowerOn", map[string]func() (bool, error){
+		devices.TransportRedfish: func() (bool, error) { return true, nil },
+	})
+	if err != nil || bmc.LastTransport("PowerOn") != devices.TransportRedfish {
+		t.Fatalf("Expected PowerOn to be served over %s: found %q, %v", devices.TransportRedfish, bmc.LastTransport("PowerOn"), err)
+	}
+
+	// a transport that fails the action doesn't serve it, the previous call isn't reported either
+	failure := fmt.Errorf("power on rejected")
+	_, err = bmc.route("PowerOn", map[string]func() (bool, error){
+		devices.TransportRedfish: func() (bool, error) { return false, failure },
+	})
+	if err != failure {
+		t.Errorf("Expected answer %v: found %v", failure, err)
+	}
+
+	if transport := bmc.LastTransport("PowerOn"); transport != "" {
+		t.Errorf("Expected no transport for the failed call: found %q", transport)
+	}
+}
@@ -21,3 +21,13 @@ type BiosSettings = cfgresources.Idrac9BiosSettings
 type TargetSettingsUri struct {
 	TargetSettingsUri string `json:"TargetSettingsURI"` //e.g /redfish/v1/Systems/System.Embedded.1/Bios/Settings
 }
+
+// ComputerSystem is the subset of redfish/v1/Systems/System.Embedded.1 used to find the supported reset types
+type ComputerSystem struct {
+	Actions struct {
+		Reset struct {
+			Target          string   `json:"target"`
+			AllowableValues []string `json:"ResetType@Redfish.AllowableValues"`
+		} `json:"#ComputerSystem.Reset"`
+	} `json:"Actions"`
+}
@@ -2,12 +2,19 @@ package idrac9
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
 
+	"github.com/bmc-toolbox/bmclib/errors"
 	"github.com/bmc-toolbox/bmclib/internal/helper"
+	"github.com/bmc-toolbox/bmclib/internal/tracing"
+	"github.com/bmc-toolbox/bmclib/providers/dell"
 	log "github.com/sirupsen/logrus"
 )
 
 func (i *IDrac9) Screenshot() (response []byte, extension string, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "Screenshot", i.ip)
+	defer func() { tracing.End(span, err) }()
 
 	extension = "png"
 
@@ -89,3 +96,26 @@ func (i *IDrac9) queryLdapRoleGroups() (ldapRoleGroups LdapRoleGroups, err error
 
 	return idracLdapRoleGroups["iDRAC.LDAPRoleGroup"], err
 }
+
+// ServiceTag returns the dell service tag of the server, upper case as printed on the asset tag
+func (i *IDrac9) ServiceTag() (serviceTag string, err error) {
+	span := tracing.Start(i.traceCtx, dell.VendorID, "ServiceTag", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return serviceTag, err
+	}
+
+	output, err := i.sshClient.Run("racadm getsysinfo")
+	if err != nil {
+		return serviceTag, fmt.Errorf("unable to read the system info: %s", output)
+	}
+
+	serviceTag = strings.ToUpper(racadmField(output, "Service Tag"))
+	if serviceTag == "" {
+		return serviceTag, errors.ErrInvalidSerial
+	}
+
+	return serviceTag, err
+}
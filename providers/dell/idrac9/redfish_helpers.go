@@ -274,6 +274,18 @@ func isRequestMethodValid(method string) (valid bool) {
 
 // GET data
 func (i *IDrac9) queryRedfish(method string, endpoint string, payload []byte) (statusCode int, response []byte, err error) {
+	statusCode, response, err = i.doRedfish(method, endpoint, payload)
+
+	// the token might have expired in the meantime, ask for a fresh one and try again
+	if err == bmclibErrors.Err401Redfish && i.tokenProvider != nil {
+		i.redfishToken = ""
+		return i.doRedfish(method, endpoint, payload)
+	}
+
+	return statusCode, response, err
+}
+
+func (i *IDrac9) doRedfish(method string, endpoint string, payload []byte) (statusCode int, response []byte, err error) {
 
 	if !isRequestMethodValid(method) {
 		return statusCode, response, fmt.Errorf("Invalid request method: %v", method)
@@ -286,7 +298,17 @@ func (i *IDrac9) queryRedfish(method string, endpoint string, payload []byte) (s
 		return statusCode, response, err
 	}
 
-	req.SetBasicAuth(i.username, i.password)
+	if i.tokenProvider != nil {
+		if i.redfishToken == "" {
+			i.redfishToken, err = i.tokenProvider()
+			if err != nil {
+				return statusCode, response, err
+			}
+		}
+		req.Header.Set("X-Auth-Token", i.redfishToken)
+	} else {
+		req.SetBasicAuth(i.username, i.password)
+	}
 	req.Header.Set("Content-Type", "application/json")
 
 	if log.GetLevel() == log.DebugLevel {
@@ -326,3 +348,57 @@ func (i *IDrac9) queryRedfish(method string, endpoint string, payload []byte) (s
 
 	return resp.StatusCode, response, err
 }
+
+// redfishReset resets the system with the given redfish ResetType, e.g. On, ForceOff or ForceRestart.
+// bmclibErrors.ErrRedFishNotSupported is returned when redfish can't be reached and
+// bmclibErrors.ErrFeatureUnavailable when the firmware doesn't offer the reset type
+func (i *IDrac9) redfishReset(resetType string) (status bool, err error) {
+	err = i.httpLogin()
+	if err != nil {
+		log.WithFields(log.Fields{"step": "redfishReset", "ip": i.ip, "error": err}).Debug("unable to login over https")
+		return status, bmclibErrors.ErrRedFishNotSupported
+	}
+
+	endpoint := "redfish/v1/Systems/System.Embedded.1"
+	statusCode, response, err := i.queryRedfish("GET", endpoint, nil)
+	if err != nil || statusCode != 200 {
+		log.WithFields(log.Fields{"step": "redfishReset", "ip": i.ip, "status": statusCode, "error": err}).Debug("unable to query the redfish system")
+		return status, bmclibErrors.ErrRedFishNotSupported
+	}
+
+	system := &ComputerSystem{}
+	err = json.Unmarshal(response, system)
+	if err != nil {
+		return status, err
+	}
+
+	supported := false
+	for _, value := range system.Actions.Reset.AllowableValues {
+		if value == resetType {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return status, bmclibErrors.ErrFeatureUnavailable
+	}
+
+	payload, err := json.Marshal(map[string]string{"ResetType": resetType})
+	if err != nil {
+		return status, err
+	}
+
+	statusCode, response, err = i.queryRedfish("POST", endpoint+"/Actions/ComputerSystem.Reset", payload)
+	if err != nil {
+		return status, err
+	}
+
+	switch statusCode {
+	case 200, 202, 204:
+		return true, err
+	case 404, 405, 501:
+		return status, bmclibErrors.ErrFeatureUnavailable
+	}
+
+	return status, fmt.Errorf("POST request to reset the system with %s, returned code: %d: %s", resetType, statusCode, response)
+}
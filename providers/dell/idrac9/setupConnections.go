@@ -1,18 +1,22 @@
 package idrac9
 
 import (
+	"context"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httputil"
+	"time"
 
+	"github.com/bmc-toolbox/bmclib/devices"
 	"github.com/bmc-toolbox/bmclib/errors"
 	"github.com/bmc-toolbox/bmclib/internal/httpclient"
 	"github.com/bmc-toolbox/bmclib/internal/sshclient"
 	"github.com/bmc-toolbox/bmclib/providers/dell"
 	multierror "github.com/hashicorp/go-multierror"
+	"golang.org/x/crypto/ssh"
 
 	// this make possible to setup logging and properties at any stage
 	_ "github.com/bmc-toolbox/bmclib/logging"
@@ -131,8 +135,22 @@ func (i *IDrac9) sshLogin() (err error) {
 		return
 	}
 
+	if i.manualLogin {
+		return errors.ErrNotLoggedIn
+	}
+
+	return i.Login()
+}
+
+// Login establishes the ssh session used by the actions, it's only required
+// to be called explicitly when the automatic login is disabled with SetAutoLogin
+func (i *IDrac9) Login() (err error) {
+	if i.sshClient != nil {
+		return
+	}
+
 	log.WithFields(log.Fields{"step": "bmc connection", "vendor": dell.VendorID, "ip": i.ip}).Debug("connecting to bmc")
-	i.sshClient, err = sshclient.New(i.ip, i.username, i.password)
+	i.sshClient, err = sshclient.NewWithOptions(i.ip, i.username, i.password, i.sshOptions)
 	if err != nil {
 		return err
 	}
@@ -140,6 +158,97 @@ func (i *IDrac9) sshLogin() (err error) {
 	return err
 }
 
+// SetAutoLogin defines whether the actions should establish the ssh session on demand,
+// when disabled the caller owns the session lifecycle via Login and Close
+func (i *IDrac9) SetAutoLogin(enable bool) {
+	i.manualLogin = !enable
+}
+
+// SetOutputEncoding defines how the ssh command output is normalized before being parsed,
+// use devices.EncodingUTF8 or devices.EncodingLatin1 when the bmc prints non utf-8 data
+func (i *IDrac9) SetOutputEncoding(encoding string) {
+	i.sshOptions.Encoding = encoding
+}
+
+// SetMaxOutputSize overrides the maximum number of bytes read from the output of a ssh command,
+// the command is aborted with a *errors.OutputTooLargeError past it. It's a few MB by default
+func (i *IDrac9) SetMaxOutputSize(size int) {
+	i.sshOptions.MaxOutputSize = size
+}
+
+// SetSSHPort overrides the port used to connect over ssh, devices.SSHPort by default
+func (i *IDrac9) SetSSHPort(port int) {
+	i.sshOptions.Port = port
+}
+
+// SetSSHTimeout overrides how long connecting over ssh may take, sshclient.DefaultTimeout by default.
+// Past it the login fails with an error wrapping errors.ErrConnectionTimeout
+func (i *IDrac9) SetSSHTimeout(timeout time.Duration) {
+	i.sshOptions.Timeout = timeout
+}
+
+// SetSSHKey makes the ssh sessions established afterwards authenticate with the given key,
+// the password is only tried when the bmc rejects it
+func (i *IDrac9) SetSSHKey(signer ssh.Signer) {
+	i.sshOptions.Signer = signer
+}
+
+// SetHostKeyCallback makes the ssh sessions established afterwards verify the host key of the bmc
+// with the given callback, e.g. one of golang.org/x/crypto/ssh/knownhosts. Any key is accepted by default
+func (i *IDrac9) SetHostKeyCallback(callback ssh.HostKeyCallback) {
+	i.sshOptions.HostKeyCallback = callback
+}
+
+// SetLogger makes the ssh sessions established afterwards pass every command run and its output,
+// truncated and with the secrets masked, to the given logger. Nothing is logged by default
+func (i *IDrac9) SetLogger(logger devices.Logger) {
+	i.sshOptions.Logger = logger
+}
+
+// SetSSHAlgorithms overrides the ciphers, key exchanges and macs offered during the ssh handshake,
+// old firmwares require devices.LegacySSHCiphers, devices.LegacySSHKeyExchanges and devices.LegacySSHMACs
+func (i *IDrac9) SetSSHAlgorithms(ciphers []string, kex []string, macs []string) {
+	i.sshOptions.Ciphers = ciphers
+	i.sshOptions.KeyExchanges = kex
+	i.sshOptions.MACs = macs
+}
+
+// SetTokenProvider makes the connection authenticate with the session tokens returned by the given
+// provider instead of the password, it's used as X-Auth-Token for redfish and answers the ssh
+// keyboard-interactive prompt. The provider is called again whenever the token is rejected
+func (i *IDrac9) SetTokenProvider(provider devices.TokenProvider) {
+	i.tokenProvider = provider
+	i.redfishToken = ""
+	i.sshOptions.TokenProvider = provider
+}
+
+// SetCommitTimeout makes CommitPending wait up to the given timeout for the commit job to be
+// scheduled, by default it returns as soon as the job is created
+func (i *IDrac9) SetCommitTimeout(timeout time.Duration) {
+	i.commitTimeout = timeout
+}
+
+// LastTimings returns how long the ssh connection took to dial and authenticate and how long
+// the last command took to run, Reused tells whether the command ran over an existing connection
+func (i *IDrac9) LastTimings() (timings devices.Timings) {
+	if i.sshClient == nil {
+		return timings
+	}
+
+	return i.sshClient.Timings()
+}
+
+// SetPollOptions defines how the wait methods poll the idrac, by default every 5 seconds or more
+// as the delay backs off, with no limit on the attempts
+func (i *IDrac9) SetPollOptions(options devices.PollOptions) {
+	i.pollOptions = options
+}
+
+// SetTraceContext defines the context the OpenTelemetry spans of the actions are attached to
+func (i *IDrac9) SetTraceContext(ctx context.Context) {
+	i.traceCtx = ctx
+}
+
 // Close closes the connection properly
 func (i *IDrac9) Close() (err error) {
 	if i.httpClient != nil {
@@ -154,7 +263,25 @@ func (i *IDrac9) Close() (err error) {
 		if e != nil {
 			err = multierror.Append(e, err)
 		}
+		i.sshClient = nil
 	}
 
 	return err
 }
+
+// Refresh drops the sessions and everything learnt about the iDRAC and logs in again. It should be
+// called once the iDRAC is back online after its firmware was updated or it was reset, otherwise
+// the inventory read at login and the redfish token are kept
+func (i *IDrac9) Refresh() (err error) {
+	// the sessions most likely died with the old firmware, failing to logout is expected
+	e := i.Close()
+	if e != nil {
+		log.WithFields(log.Fields{"step": "bmc connection", "vendor": dell.VendorID, "ip": i.ip, "error": e}).Debug("unable to close the previous sessions")
+	}
+	i.httpClient = nil
+	i.xsrfToken = ""
+	i.redfishToken = ""
+	i.iDracInventory = nil
+
+	return i.httpLogin()
+}
@@ -0,0 +1,73 @@
+package idrac9
+
+import (
+	"github.com/bmc-toolbox/bmclib/devices"
+	"github.com/bmc-toolbox/bmclib/errors"
+	"github.com/bmc-toolbox/bmclib/providers/dell"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultTransports is the order the transports are tried for the actions served by
+// both redfish and ssh, redfish goes first as it doesn't depend on the racadm output.
+// Only the power actions are routed, the iDRAC9 has no SEL reader over either transport yet
+var defaultTransports = map[string][]string{
+	"PowerCycle": {devices.TransportRedfish, devices.TransportSSH},
+	"PowerOff":   {devices.TransportRedfish, devices.TransportSSH},
+	"PowerOn":    {devices.TransportRedfish, devices.TransportSSH},
+}
+
+// SetTransportOrder overrides the order the transports are tried for the given action, e.g.
+// SetTransportOrder("PowerOn", devices.TransportSSH) never tries redfish to power on the server
+func (i *IDrac9) SetTransportOrder(action string, transports ...string) {
+	if i.transports == nil {
+		i.transports = make(map[string][]string)
+	}
+	i.transports[action] = transports
+}
+
+// LastTransport returns the transport that served the last call of the given action,
+// it's empty when the action wasn't called yet or its last call failed
+func (i *IDrac9) LastTransport(action string) (transport string) {
+	return i.lastTransport[action]
+}
+
+// route runs the action over the first transport supporting it, falling back to the
+// next one when the firmware doesn't implement the action on the current transport
+func (i *IDrac9) route(action string, handlers map[string]func() (bool, error)) (status bool, err error) {
+	transports, ok := i.transports[action]
+	if !ok {
+		transports = defaultTransports[action]
+	}
+
+	err = errors.ErrFeatureUnavailable
+	for _, transport := range transports {
+		handler, ok := handlers[transport]
+		if !ok {
+			continue
+		}
+
+		status, err = handler()
+		if err == errors.ErrFeatureUnavailable || err == errors.ErrPageNotFound || err == errors.ErrRedFishNotSupported {
+			log.WithFields(log.Fields{"step": action, "vendor": dell.VendorID, "ip": i.ip, "transport": transport}).Debug("action not supported, trying the next transport")
+			continue
+		}
+
+		if err != nil {
+			delete(i.lastTransport, action)
+			return status, err
+		}
+
+		if i.lastTransport == nil {
+			i.lastTransport = make(map[string]string)
+		}
+		i.lastTransport[action] = transport
+		log.WithFields(log.Fields{"step": action, "vendor": dell.VendorID, "ip": i.ip, "transport": transport}).Debug("action served")
+
+		return status, err
+	}
+
+	delete(i.lastTransport, action)
+
+	return false, err
+}
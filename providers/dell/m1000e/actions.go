@@ -404,3 +404,26 @@ func (m *M1000e) RemoveBladeBmcUser(username string) (err error) {
 func (m *M1000e) ModBladeBmcUser(username string, password string) (err error) {
 	return errors.ErrNotImplemented
 }
+
+// PowerOnSlot power on the server in the given slot
+func (m *M1000e) PowerOnSlot(position int) (status bool, err error) {
+	return m.PowerOnBlade(position)
+}
+
+// PowerOffSlot power off the server in the given slot
+func (m *M1000e) PowerOffSlot(position int) (status bool, err error) {
+	return m.PowerOffBlade(position)
+}
+
+// SlotPowerState returns the power state of the server in the given slot, on or off
+func (m *M1000e) SlotPowerState(position int) (state string, err error) {
+	status, err := m.IsOnBlade(position)
+	if err != nil {
+		return state, err
+	}
+
+	if status {
+		return "on", err
+	}
+	return "off", err
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/bmc-toolbox/bmclib/devices"
 	"github.com/bmc-toolbox/bmclib/errors"
+	"github.com/bmc-toolbox/bmclib/internal/helper"
 	"github.com/bmc-toolbox/bmclib/internal/httpclient"
 	"github.com/bmc-toolbox/bmclib/internal/sshclient"
 	"github.com/bmc-toolbox/bmclib/providers/dell"
@@ -390,11 +391,36 @@ func (m *M1000e) Blades() (blades []*devices.Blade, err error) {
 	return blades, err
 }
 
+// Slots returns the slots of the chassis populated with servers
+func (m *M1000e) Slots() (slots []*devices.SlotInfo, err error) {
+	blades, err := m.Blades()
+	if err != nil {
+		return slots, err
+	}
+
+	for _, blade := range blades {
+		slots = append(slots, &devices.SlotInfo{
+			Position:   blade.BladePosition,
+			Name:       blade.Name,
+			Serial:     blade.Serial,
+			Model:      blade.Model,
+			PowerState: blade.PowerState,
+			Status:     blade.Status,
+		})
+	}
+	return slots, err
+}
+
 // Vendor returns bmc's vendor
 func (m *M1000e) Vendor() (vendor string) {
 	return dell.VendorID
 }
 
+// SupportedActions returns the names of the action methods implemented by this provider
+func (m *M1000e) SupportedActions() (actions []string) {
+	return helper.SupportedActions(m)
+}
+
 // ChassisSnapshot do best effort to populate the server data and returns a blade or discrete
 func (m *M1000e) ChassisSnapshot() (chassis *devices.Chassis, err error) {
 	chassis = &devices.Chassis{}
@@ -631,6 +631,7 @@ func TestChassisInterface(t *testing.T) {
 		t.Fatalf("Found errors during the test setup %v", err)
 	}
 	_ = devices.BmcChassis(chassis)
+	_ = devices.SlotChassis(chassis)
 	tearDown()
 }
 
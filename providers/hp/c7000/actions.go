@@ -420,3 +420,26 @@ func (c *C7000) SetFlexAddressState(position int, enable bool) (status bool, err
 func (c *C7000) SetIpmiOverLan(position int, enable bool) (status bool, err error) {
 	return status, errors.ErrNotImplemented
 }
+
+// PowerOnSlot power on the server in the given slot
+func (c *C7000) PowerOnSlot(position int) (status bool, err error) {
+	return c.PowerOnBlade(position)
+}
+
+// PowerOffSlot power off the server in the given slot
+func (c *C7000) PowerOffSlot(position int) (status bool, err error) {
+	return c.PowerOffBlade(position)
+}
+
+// SlotPowerState returns the power state of the server in the given slot, on or off
+func (c *C7000) SlotPowerState(position int) (state string, err error) {
+	status, err := c.IsOnBlade(position)
+	if err != nil {
+		return state, err
+	}
+
+	if status {
+		return "on", err
+	}
+	return "off", err
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/bmc-toolbox/bmclib/devices"
 	"github.com/bmc-toolbox/bmclib/errors"
+	"github.com/bmc-toolbox/bmclib/internal/helper"
 	"github.com/bmc-toolbox/bmclib/internal/httpclient"
 	"github.com/bmc-toolbox/bmclib/internal/sshclient"
 	"github.com/bmc-toolbox/bmclib/providers/hp"
@@ -229,11 +230,36 @@ func (c *C7000) Blades() (blades []*devices.Blade, err error) {
 	return blades, err
 }
 
+// Slots returns the slots of the chassis populated with servers
+func (c *C7000) Slots() (slots []*devices.SlotInfo, err error) {
+	blades, err := c.Blades()
+	if err != nil {
+		return slots, err
+	}
+
+	for _, blade := range blades {
+		slots = append(slots, &devices.SlotInfo{
+			Position:   blade.BladePosition,
+			Name:       blade.Name,
+			Serial:     blade.Serial,
+			Model:      blade.Model,
+			PowerState: blade.PowerState,
+			Status:     blade.Status,
+		})
+	}
+	return slots, err
+}
+
 // Vendor returns bmc's vendor
 func (c *C7000) Vendor() (vendor string) {
 	return hp.VendorID
 }
 
+// SupportedActions returns the names of the action methods implemented by this provider
+func (c *C7000) SupportedActions() (actions []string) {
+	return helper.SupportedActions(c)
+}
+
 // ChassisSnapshot do best effort to populate the server data and returns a blade or discrete
 func (c *C7000) ChassisSnapshot() (chassis *devices.Chassis, err error) {
 	chassis = &devices.Chassis{}
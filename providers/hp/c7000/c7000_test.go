@@ -2702,6 +2702,7 @@ func TestHpChassisInterface(t *testing.T) {
 		t.Fatalf("Found errors during the test setup %v", err)
 	}
 	_ = devices.BmcChassis(chassis)
+	_ = devices.SlotChassis(chassis)
 	tearDown()
 }
 
@@ -2739,3 +2740,34 @@ func TestUpdateCredentials(t *testing.T) {
 
 	tearDown()
 }
+
+func TestHpChassisSlots(t *testing.T) {
+	expectedAnswer := &devices.SlotInfo{
+		Position:   1,
+		Name:       "bbmi",
+		Serial:     "cz3521yaek",
+		Model:      "ProLiant BL460c Gen9",
+		PowerState: "on",
+		Status:     "OK",
+	}
+
+	chassis, err := setup()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+
+	slots, err := chassis.Slots()
+	if err != nil {
+		t.Fatalf("Found errors calling chassis.Slots %v", err)
+	}
+
+	if len(slots) != 7 {
+		t.Errorf("Expected 7 slots: found %d", len(slots))
+	}
+
+	if len(slots) > 0 && *slots[0] != *expectedAnswer {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, slots[0])
+	}
+
+	tearDown()
+}
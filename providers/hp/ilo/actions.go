@@ -1,20 +1,31 @@
 package ilo
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"strings"
 
+	"github.com/bmc-toolbox/bmclib/devices"
+	"github.com/bmc-toolbox/bmclib/errors"
 	"github.com/bmc-toolbox/bmclib/internal/ipmi"
+	"github.com/bmc-toolbox/bmclib/internal/tracing"
+	"github.com/bmc-toolbox/bmclib/providers/hp"
+
+	log "github.com/sirupsen/logrus"
 )
 
 // PowerCycle reboots the machine via bmc
 func (i *Ilo) PowerCycle() (status bool, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "PowerCycle", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.sshLogin()
 	if err != nil {
 		return status, err
 	}
 
-	output, err := i.sshClient.Run("power reset")
+	output, err := i.run("power reset")
 	if err != nil {
 		return false, fmt.Errorf(output)
 	}
@@ -30,33 +41,73 @@ func (i *Ilo) PowerCycle() (status bool, err error) {
 	return status, fmt.Errorf(output)
 }
 
-// PowerCycleBmc reboots the bmc we are connected to
-func (i *Ilo) PowerCycleBmc() (status bool, err error) {
+// PowerCycleWith reboots the machine via bmc using the given reset type, a warm reset is
+// the same as PowerCycle while a cold reset forces the server off before powering it on
+func (i *Ilo) PowerCycleWith(resetType devices.ResetType) (status bool, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "PowerCycleWith", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	if resetType == devices.ResetWarm {
+		return i.PowerCycle()
+	}
+
+	if resetType != devices.ResetCold {
+		return status, errors.ErrFeatureUnavailable
+	}
+
 	err = i.sshLogin()
 	if err != nil {
 		return status, err
 	}
 
-	output, err := i.sshClient.Run("reset /map1")
-	if err != nil && !strings.Contains(output, "Resetting iLO") {
+	output, err := i.run("power off hard")
+	if err != nil {
 		return false, fmt.Errorf(output)
 	}
 
-	if strings.Contains(output, "Resetting iLO") {
+	if !strings.Contains(output, "Forcing server") && !strings.Contains(output, "Server power already off") {
+		return status, fmt.Errorf(output)
+	}
+
+	return i.PowerOn()
+}
+
+// PowerCycleBmc reboots the bmc we are connected to
+func (i *Ilo) PowerCycleBmc() (status bool, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "PowerCycleBmc", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return status, err
+	}
+
+	output, err := i.run("reset /map1")
+	if strings.Contains(output, "Resetting iLO") || sessionDropped(err) {
+		// the iLO goes down with the session, sometimes before its reply or exit status is flushed
+		log.WithFields(log.Fields{"step": "PowerCycleBmc", "vendor": hp.VendorID, "ip": i.ip, "error": err}).Debug("iLO resetting, dropping the ssh session")
+		i.closeSSH()
 		return true, nil
 	}
 
+	if err != nil {
+		return false, fmt.Errorf(output)
+	}
+
 	return status, fmt.Errorf(output)
 }
 
 // PowerOn power on the machine via bmc
 func (i *Ilo) PowerOn() (status bool, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "PowerOn", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.sshLogin()
 	if err != nil {
 		return status, err
 	}
 
-	output, err := i.sshClient.Run("power on")
+	output, err := i.run("power on")
 	if err != nil {
 		return false, fmt.Errorf(output)
 	}
@@ -69,12 +120,15 @@ func (i *Ilo) PowerOn() (status bool, err error) {
 
 // PowerOff power off the machine via bmc
 func (i *Ilo) PowerOff() (status bool, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "PowerOff", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.sshLogin()
 	if err != nil {
 		return status, err
 	}
 
-	output, err := i.sshClient.Run("power off hard")
+	output, err := i.run("power off hard")
 	if err != nil {
 		return false, fmt.Errorf(output)
 	}
@@ -85,8 +139,104 @@ func (i *Ilo) PowerOff() (status bool, err error) {
 	return status, fmt.Errorf(output)
 }
 
-// PxeOnce makes the machine to boot via pxe once
+// PressPowerButton emulates a press of the power button, a held press forces the machine
+// off while a momentary press signals the operating system to shutdown
+func (i *Ilo) PressPowerButton(hold bool) (status bool, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "PressPowerButton", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return status, err
+	}
+
+	command := "power off"
+	if hold {
+		command = "power off hard"
+	}
+
+	output, err := i.run(command)
+	if err != nil {
+		return false, fmt.Errorf(output)
+	}
+
+	if strings.Contains(output, "Server powering off") || strings.Contains(output, "Forcing server") {
+		return true, err
+	}
+
+	return status, fmt.Errorf(output)
+}
+
+// CreateUser adds a user account to the bmc granted the given iLO privileges, see userPrivileges.
+// Without privileges the account can only login and read the status of the server
+func (i *Ilo) CreateUser(username string, password string, privileges []string) (status bool, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "CreateUser", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	if username == "" || strings.ContainsAny(username, " \t\"/") {
+		return status, fmt.Errorf("invalid username: %q", username)
+	}
+
+	if password == "" || strings.ContainsAny(password, " \t\"") {
+		return status, fmt.Errorf("invalid password for %s: it can't be empty nor contain spaces or quotes", username)
+	}
+
+	err = validatePrivileges(privileges)
+	if err != nil {
+		return status, err
+	}
+
+	err = i.sshLogin()
+	if err != nil {
+		return status, err
+	}
+
+	command := fmt.Sprintf("create /map1/accounts1 username=%s password=%s name=%s", username, password, username)
+	if len(privileges) > 0 {
+		command = fmt.Sprintf("%s group=%s", command, strings.Join(privileges, ","))
+	}
+
+	output, err := i.run(command)
+	if err != nil {
+		return false, fmt.Errorf(output)
+	}
+
+	if strings.Contains(output, "COMMAND COMPLETED") {
+		return true, err
+	}
+
+	return status, fmt.Errorf(output)
+}
+
+// DeleteUser removes the given user account from the bmc
+func (i *Ilo) DeleteUser(username string) (status bool, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "DeleteUser", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return status, err
+	}
+
+	output, err := i.sshClient.RunWithConfirmation(fmt.Sprintf("delete /map1/accounts1/%s", username))
+	if err != nil {
+		return false, fmt.Errorf(output)
+	}
+
+	if strings.Contains(output, "COMMAND COMPLETED") {
+		return true, err
+	}
+
+	return status, fmt.Errorf(output)
+}
+
+// PxeOnce makes the machine to boot via pxe once and power cycles it. It goes over ipmi as the
+// ssh cli only exposes the persistent boot order (bootsourceN bootorder), setting the network
+// first there would keep the machine booting from pxe after the reprovisioning
 func (i *Ilo) PxeOnce() (status bool, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "PxeOnce", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	im, err := ipmi.New(i.username, i.password, i.ip)
 	if err != nil {
 		return status, err
@@ -108,17 +258,126 @@ func (i *Ilo) PxeOnce() (status bool, err error) {
 
 // IsOn tells if a machine is currently powered on
 func (i *Ilo) IsOn() (status bool, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "IsOn", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	state, err := i.PowerState()
+	if err != nil {
+		return status, err
+	}
+
+	return state == devices.PowerStateOn, err
+}
+
+// RecoveryCounters returns the ASR reboots and the NMIs counted in the automatic server recovery status of the iLO
+func (i *Ilo) RecoveryCounters() (stats devices.RecoveryStats, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "RecoveryCounters", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	asr, err := i.show(asrTarget)
+	if err != nil {
+		return stats, err
+	}
+
+	return parseASRStatus(asr.Properties)
+}
+
+// ResetRecoveryCounters zeroes the ASR and NMI counters of the automatic server recovery status,
+// the event logs are left as they are
+func (i *Ilo) ResetRecoveryCounters() (status bool, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "ResetRecoveryCounters", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.sshLogin()
 	if err != nil {
 		return status, err
 	}
 
-	output, err := i.sshClient.Run("power")
+	output, err := i.run(fmt.Sprintf("set %s oemhp_asr_count=0 oemhp_nmi_count=0", asrTarget))
 	if err != nil {
-		return false, fmt.Errorf("%v: %v", err, output)
+		return false, fmt.Errorf(output)
 	}
-	if strings.Contains(output, "currently: On") {
+
+	if strings.Contains(output, "COMMAND COMPLETED") {
 		return true, err
 	}
+
 	return status, fmt.Errorf(output)
 }
+
+// GetPowerRestorePolicy returns the auto power-on behaviour after an AC loss using ipmi
+func (i *Ilo) GetPowerRestorePolicy() (policy devices.PowerRestorePolicy, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "GetPowerRestorePolicy", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	im, err := ipmi.New(i.username, i.password, i.ip)
+	if err != nil {
+		return policy, err
+	}
+	return im.GetPowerRestorePolicy()
+}
+
+// SetPowerRestorePolicy defines the auto power-on behaviour after an AC loss using ipmi
+func (i *Ilo) SetPowerRestorePolicy(policy devices.PowerRestorePolicy) (status bool, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "SetPowerRestorePolicy", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	im, err := ipmi.New(i.username, i.password, i.ip)
+	if err != nil {
+		return status, err
+	}
+	return im.SetPowerRestorePolicy(policy)
+}
+
+// SetBootMode defines the boot mode of the server, it's applied on the next reboot so pendingReboot
+// is returned true. Switching the boot mode resets the boot order, it needs to be applied again afterwards
+func (i *Ilo) SetBootMode(mode devices.BootMode) (pendingReboot bool, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "SetBootMode", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	if mode != devices.BootModeUEFI && mode != devices.BootModeLegacy {
+		return pendingReboot, fmt.Errorf("unknown boot mode: %s", mode)
+	}
+
+	err = i.sshLogin()
+	if err != nil {
+		return pendingReboot, err
+	}
+
+	output, err := i.run(fmt.Sprintf("set /system1/bootconfig1 oemhp_bootmode=%s", mode))
+	if err != nil {
+		return false, fmt.Errorf(output)
+	}
+
+	if strings.Contains(output, "COMMAND COMPLETED") {
+		return true, err
+	}
+
+	return pendingReboot, fmt.Errorf(output)
+}
+
+// ChassisIntrusion reads the intrusion sensor using ipmi, errors.ErrFeatureUnavailable
+// is returned when none is fitted
+func (i *Ilo) ChassisIntrusion() (status devices.IntrusionStatus, err error) {
+	im, err := ipmi.New(i.username, i.password, i.ip)
+	if err != nil {
+		return devices.IntrusionUnknown, err
+	}
+
+	return im.ChassisIntrusion()
+}
+
+// SOL opens the serial over lan console of the machine using ipmi, it's closed when ctx is done.
+// The console output is copied to record when given. A console in use by another session is
+// reported as *errors.SOLBusyError, force disconnects that session instead
+func (i *Ilo) SOL(ctx context.Context, record io.Writer, force bool) (console io.ReadWriteCloser, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "SOL", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	im, err := ipmi.New(i.username, i.password, i.ip)
+	if err != nil {
+		return console, err
+	}
+
+	return im.SOL(ctx, record, force)
+}
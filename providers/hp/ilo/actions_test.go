@@ -8,8 +8,13 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/bmc-toolbox/bmclib/devices"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -23,10 +28,234 @@ var (
 		"reset /map1":    []byte(`Resetting iLO`),
 		"power on":       []byte(`Server powering on .......`),
 		"power off hard": []byte(`Forcing server power off .......`),
+		"power off":      []byte(`Server powering off .......`),
 		"power":          []byte(`power: server power is currently: On`),
+		"show /map1/oemhp_vm1": []byte(`status=0
+status_tag=COMMAND COMPLETED
+Tue Feb 13 10:02:50 2018
+
+
+
+/map1/oemhp_vm1
+  Targets
+    floppydr1
+    cddr1
+  Properties
+  Verbs
+    cd version exit show
+
+
+`),
+		"show /map1/oemhp_vm1/floppydr1": []byte(`status=0
+status_tag=COMMAND COMPLETED
+Tue Feb 13 10:02:50 2018
+
+
+
+/map1/oemhp_vm1/floppydr1
+  Targets
+  Properties
+    oemhp_image=None
+    oemhp_connect=No
+    oemhp_boot=No_Boot
+    oemhp_wp=No
+    vm_applet=Disconnected
+  Verbs
+    cd version exit show set
+
+
+`),
+		"show /map1/oemhp_vm1/cddr1": []byte(`status=0
+status_tag=COMMAND COMPLETED
+Tue Feb 13 10:02:50 2018
+
+
+
+/map1/oemhp_vm1/cddr1
+  Targets
+  Properties
+    oemhp_image=http://images.example.com/rescue.iso
+    oemhp_connect=Yes
+    oemhp_boot=Once
+    oemhp_wp=Yes
+    vm_applet=Disconnected
+  Verbs
+    cd version exit show set
+
+
+`),
+		"show /system1": []byte(`status=0
+status_tag=COMMAND COMPLETED
+Tue Feb 13 10:02:48 2018
+
+
+
+/system1
+  Targets
+    firmware1
+    bootconfig1
+    log1
+    led1
+    network1
+    oemhp_power1
+    cpu1
+    cpu2
+    memory1
+    slot1
+    fan1
+    sensor1
+    sensor2
+    sensor3
+    swid1
+  Properties
+    name=ProLiant DL360 Gen9
+    number=CZ3521YAEK
+    oemhp_server_name=bbmi.example.com
+    enabled_state=enabled
+    processor_number=2
+  Verbs
+    cd version exit show reset start stop set
+
+
+`),
+		"show /system1/sensor1": []byte(`status=0
+status_tag=COMMAND COMPLETED
+Tue Feb 13 10:02:49 2018
+
+
+
+/system1/sensor1
+  Targets
+  Properties
+    ElementName=Temp 1
+    OperationalStatus=Ok
+    RateUnits=Celsius
+    CurrentReading=21
+    SensorType=Temperature
+    HealthState=Ok
+    oemhp_CautionValue=42
+    oemhp_CriticalValue=46
+  Verbs
+    cd version exit show
+
+
+`),
+		"show /system1/sensor2": []byte(`status=0
+status_tag=COMMAND COMPLETED
+Tue Feb 13 10:02:49 2018
+
+
+
+/system1/sensor2
+  Targets
+  Properties
+    ElementName=Temp 2
+    OperationalStatus=Degraded
+    RateUnits=Celsius
+    CurrentReading=43
+    SensorType=Temperature
+    HealthState=Degraded
+    oemhp_CautionValue=42
+    oemhp_CriticalValue=46
+  Verbs
+    cd version exit show
+
+
+`),
+		"show /system1/sensor3": []byte(`status=0
+status_tag=COMMAND COMPLETED
+Tue Feb 13 10:02:50 2018
+
+
+
+/system1/sensor3
+  Targets
+  Properties
+    ElementName=Fan Block 1
+    OperationalStatus=Ok
+    RateUnits=Percent
+    CurrentReading=19
+    SensorType=Fan
+    HealthState=Ok
+  Verbs
+    cd version exit show
+
+
+`),
+		"show /system1/bootconfig1": []byte(`status=0
+status_tag=COMMAND COMPLETED
+Tue Feb 13 10:05:12 2018
+
+
+
+/system1/bootconfig1
+  Targets
+    bootsource1
+    bootsource2
+    bootsource3
+    bootsource4
+    bootsource5
+  Properties
+    oemhp_bootmode=Legacy
+    oemhp_secureboot=no
+  Verbs
+    cd version exit show set
+
+
+`),
+		"set /system1/bootconfig1 oemhp_bootmode=UEFI": []byte("status=0\nstatus_tag=COMMAND COMPLETED\n"),
+		"delete /map1/accounts1/automation":            []byte("status=0\nstatus_tag=COMMAND COMPLETED\n"),
+		"create /map1/accounts1 username=automation password=secret name=automation group=oemhp_power,oemhp_vm": []byte("status=0\nstatus_tag=COMMAND COMPLETED\n"),
+		"show /system1/oemhp_asr1": []byte(`status=0
+status_tag=COMMAND COMPLETED
+Tue Feb 13 10:02:50 2018
+
+
+
+/system1/oemhp_asr1
+  Targets
+  Properties
+    oemhp_asr_status=enabled
+    oemhp_asr_count=2
+    oemhp_asr_last=03/12/2019 10:42:07
+    oemhp_nmi_count=1
+  Verbs
+    cd version exit show set
+
+
+`),
+		"set /system1/oemhp_asr1 oemhp_asr_count=0 oemhp_nmi_count=0": []byte("status=0\nstatus_tag=COMMAND COMPLETED\n"),
 	}
 )
 
+var (
+	sshConnsMutex sync.Mutex
+	// sshConns holds the connections accepted by the ssh server
+	sshConns []net.Conn
+)
+
+// dropSSHConnections closes the connections accepted so far, as the iLO does with the idle sessions
+func dropSSHConnections() (dropped int) {
+	sshConnsMutex.Lock()
+	defer sshConnsMutex.Unlock()
+
+	for _, conn := range sshConns {
+		conn.Close()
+	}
+	dropped = len(sshConns)
+	sshConns = nil
+
+	return dropped
+}
+
+// sshDrops holds the commands after which the session is closed without any exit status,
+// as the iLO does while resetting itself
+var sshDrops = map[string][]byte{}
+
+// sshHangups holds the commands the connection is closed on once received, counting how many
+// times each one reached the iLO while its answer was lost
+var sshHangups = map[string]int{}
+
 func generatePrivateKey(bitSize int) (pk *rsa.PrivateKey, err error) {
 	pk, err = rsa.GenerateKey(rand.Reader, bitSize)
 	if err != nil {
@@ -64,6 +293,10 @@ func runSSHServer(config *ssh.ServerConfig, loading chan interface{}) {
 			break
 		}
 
+		sshConnsMutex.Lock()
+		sshConns = append(sshConns, conn)
+		sshConnsMutex.Unlock()
+
 		_, chans, reqs, err := ssh.NewServerConn(conn, config)
 		if err != nil {
 			log.Printf("Failed to handshake (%s)", err)
@@ -103,7 +336,21 @@ func handleChannel(newChannel ssh.NewChannel) {
 				if err := ssh.Unmarshal(req.Payload, &reqCmd); err != nil {
 					log.Printf("failed: %v\n", err)
 				}
-				if answer, ok := sshAnswers[reqCmd.Text]; ok {
+				sshConnsMutex.Lock()
+				_, hangup := sshHangups[reqCmd.Text]
+				if hangup {
+					sshHangups[reqCmd.Text]++
+				}
+				sshConnsMutex.Unlock()
+
+				if hangup {
+					req.Reply(req.WantReply, nil)
+					dropSSHConnections()
+				} else if answer, ok := sshDrops[reqCmd.Text]; ok {
+					// the bmc goes away without sending the exit status
+					channel.Write(answer)
+					req.Reply(req.WantReply, nil)
+				} else if answer, ok := sshAnswers[reqCmd.Text]; ok {
 					if len(answer) == 0 {
 						channel.Stderr().Write([]byte(fmt.Sprintf("answer empty for %s", reqCmd.Text)))
 						req.Reply(req.WantReply, nil)
@@ -209,6 +456,127 @@ func TestIloPowerCycleBmc(t *testing.T) {
 	}
 }
 
+func TestIloPowerCycleBmcSessionDropped(t *testing.T) {
+	tt := []struct {
+		name   string
+		output string
+	}{
+		{name: "after the reply", output: "Resetting iLO"},
+		{name: "before the reply", output: ""},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			sshDrops["reset /map1"] = []byte(tc.output)
+			defer delete(sshDrops, "reset /map1")
+
+			bmc, err := setupSSH()
+			if err != nil {
+				t.Fatalf("Found errors during the test setup %v", err)
+			}
+			defer tearDownSSH()
+
+			answer, err := bmc.PowerCycleBmc()
+			if err != nil {
+				t.Fatalf("Found errors calling bmc.PowerCycleBmc %v", err)
+			}
+
+			if answer != true {
+				t.Errorf("Expected answer %v: found %v", true, answer)
+			}
+
+			if bmc.sshClient != nil {
+				t.Errorf("Expected the dropped ssh session to be closed")
+			}
+		})
+	}
+}
+
+func TestIloPowerCycleBmcNotSent(t *testing.T) {
+	bmc, err := setupSSH()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+	defer tearDownSSH()
+
+	err = bmc.Login()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+
+	// the session is gone before the command is sent and the iLO doesn't take the reconnection
+	sshServer.Close()
+	dropSSHConnections()
+
+	answer, err := bmc.PowerCycleBmc()
+	if err == nil || answer {
+		t.Errorf("Expected bmc.PowerCycleBmc to fail: found %v, %v", answer, err)
+	}
+}
+
+func TestIloReconnect(t *testing.T) {
+	bmc, err := setupSSH()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+	defer tearDownSSH()
+
+	// the connections left by the previous tests
+	dropSSHConnections()
+
+	err = bmc.Login()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+
+	if dropped := dropSSHConnections(); dropped != 1 {
+		t.Fatalf("Expected answer %v: found %v", 1, dropped)
+	}
+
+	answer, err := bmc.PowerState()
+	if err != nil {
+		t.Fatalf("Found errors calling bmc.PowerState %v", err)
+	}
+
+	if answer != devices.PowerStateOn {
+		t.Errorf("Expected answer %v: found %v", devices.PowerStateOn, answer)
+	}
+
+	if reconnected := dropSSHConnections(); reconnected != 1 {
+		t.Errorf("Expected a single reconnection: found %v", reconnected)
+	}
+}
+
+func TestIloReconnectNotRetried(t *testing.T) {
+	bmc, err := setupSSH()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+	defer tearDownSSH()
+
+	sshConnsMutex.Lock()
+	sshHangups["power off hard"] = 0
+	sshConnsMutex.Unlock()
+	defer delete(sshHangups, "power off hard")
+
+	err = bmc.Login()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+
+	// the connection is lost once the command reached the iLO, running it again could do it twice
+	answer, err := bmc.PowerOff()
+	if err == nil || answer {
+		t.Errorf("Expected bmc.PowerOff to fail: found %v, %v", answer, err)
+	}
+
+	sshConnsMutex.Lock()
+	defer sshConnsMutex.Unlock()
+	if sent := sshHangups["power off hard"]; sent != 1 {
+		t.Errorf("Expected the command to be sent once: found %v", sent)
+	}
+}
+
 func TestIloPowerOn(t *testing.T) {
 	expectedAnswer := true
 
@@ -247,6 +615,327 @@ func TestIloPowerOff(t *testing.T) {
 	}
 }
 
+func TestIloPressPowerButton(t *testing.T) {
+	expectedAnswer := true
+
+	bmc, err := setupSSH()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+	defer tearDownSSH()
+
+	for _, hold := range []bool{false, true} {
+		answer, err := bmc.PressPowerButton(hold)
+		if err != nil {
+			t.Fatalf("Found errors calling bmc.PressPowerButton(%v) %v", hold, err)
+		}
+
+		if answer != expectedAnswer {
+			t.Errorf("Expected answer %v: found %v", expectedAnswer, answer)
+		}
+	}
+}
+
+func TestIloDeleteUser(t *testing.T) {
+	expectedAnswer := true
+
+	bmc, err := setupSSH()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+
+	answer, err := bmc.DeleteUser("automation")
+	if err != nil {
+		t.Fatalf("Found errors calling bmc.DeleteUser %v", err)
+	}
+
+	if answer != expectedAnswer {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, answer)
+	}
+
+	tearDownSSH()
+}
+
+func TestIloCreateUser(t *testing.T) {
+	expectedAnswer := true
+
+	bmc, err := setupSSH()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+	defer tearDownSSH()
+
+	answer, err := bmc.CreateUser("automation", "secret", []string{"oemhp_power", "oemhp_vm"})
+	if err != nil {
+		t.Fatalf("Found errors calling bmc.CreateUser %v", err)
+	}
+
+	if answer != expectedAnswer {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, answer)
+	}
+}
+
+func TestIloCreateUserValidation(t *testing.T) {
+	tt := []struct {
+		name       string
+		username   string
+		password   string
+		privileges []string
+	}{
+		{name: "unknown privilege", username: "automation", password: "secret", privileges: []string{"oemhp_power", "root"}},
+		{name: "empty username", username: "", password: "secret", privileges: []string{"admin"}},
+		{name: "username with spaces", username: "auto mation", password: "secret", privileges: []string{"admin"}},
+		{name: "empty password", username: "automation", password: "", privileges: []string{"admin"}},
+	}
+
+	// there's no bmc listening, the validation has to fail before connecting
+	bmc := &Ilo{ip: "127.0.0.1", username: "super", password: "test"}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			status, err := bmc.CreateUser(tc.username, tc.password, tc.privileges)
+			if err == nil || !strings.HasPrefix(err.Error(), "invalid ") {
+				t.Errorf("Expected a validation error: found %v", err)
+			}
+
+			if status {
+				t.Errorf("Expected answer %v: found %v", false, status)
+			}
+
+			if bmc.sshClient != nil {
+				t.Errorf("Expected no ssh session to be established")
+			}
+		})
+	}
+}
+
+func TestIloInventoryInterface(t *testing.T) {
+	bmc, err := setupSSH()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+	defer tearDownSSH()
+
+	// the ssh and http mocks listen on different ports, the ssh session is established first
+	err = bmc.Login()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+	bmc.ip = strings.TrimPrefix(server.URL, "https://")
+
+	var inventory devices.Inventory = bmc
+	tt := []struct {
+		name     string
+		read     func() (string, error)
+		expected string
+	}{
+		{name: "Serial", read: inventory.Serial, expected: "cz3605020d"},
+		{name: "BiosVersion", read: inventory.BiosVersion, expected: "P89 v2.42 (04/25/2017)"},
+		{name: "BmcVersion", read: inventory.BmcVersion, expected: "2.54"},
+		{name: "PowerState", read: inventory.PowerState, expected: devices.PowerStateOn},
+	}
+
+	for _, tc := range tt {
+		answer, err := tc.read()
+		if err != nil {
+			t.Fatalf("Found errors calling bmc.%s %v", tc.name, err)
+		}
+
+		if answer != tc.expected {
+			t.Errorf("%s: Expected answer %v: found %v", tc.name, tc.expected, answer)
+		}
+	}
+}
+
+func TestIloParseShow(t *testing.T) {
+	root, err := parseShow(string(sshAnswers["show /system1"]))
+	if err != nil {
+		t.Fatalf("Found errors calling parseShow %v", err)
+	}
+
+	if root.Path != "/system1" || len(root.Targets) != 15 || root.Targets[13].Path != "/system1/sensor3" {
+		t.Errorf("Expected /system1 with 15 targets: found %v with %v", root.Path, root.Targets)
+	}
+
+	if root.Properties["processor_number"] != "2" {
+		t.Errorf("Expected answer %v: found %v", "2", root.Properties["processor_number"])
+	}
+
+	if len(root.Verbs) != 8 || root.Verbs[3] != "show" {
+		t.Errorf("Expected 8 verbs: found %v", root.Verbs)
+	}
+
+	nested, err := parseShow(string(sshAnswers["show /system1"]) + string(sshAnswers["show /system1/sensor2"]))
+	if err != nil {
+		t.Fatalf("Found errors calling parseShow %v", err)
+	}
+
+	if nested.Targets[12].Properties["CurrentReading"] != "43" {
+		t.Errorf("Expected answer %v: found %v", "43", nested.Targets[12].Properties)
+	}
+}
+
+func TestIloDeviceInfo(t *testing.T) {
+	expectedAnswer := DeviceInfo{
+		Model:      "ProLiant DL360 Gen9",
+		Serial:     "cz3521yaek",
+		Hostname:   "bbmi.example.com",
+		PowerState: "on",
+	}
+
+	bmc, err := setupSSH()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+
+	answer, err := bmc.DeviceInfo()
+	if err != nil {
+		t.Fatalf("Found errors calling bmc.DeviceInfo %v", err)
+	}
+
+	if answer != expectedAnswer {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, answer)
+	}
+
+	tearDownSSH()
+}
+
+func TestIloHealth(t *testing.T) {
+	expectedAnswer := Health{
+		Overall: "Degraded",
+		Sensors: map[string]string{"Temp 1": "Ok", "Temp 2": "Degraded", "Fan Block 1": "Ok"},
+	}
+
+	bmc, err := setupSSH()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+
+	answer, err := bmc.Health()
+	if err != nil {
+		t.Fatalf("Found errors calling bmc.Health %v", err)
+	}
+
+	if !reflect.DeepEqual(answer, expectedAnswer) {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, answer)
+	}
+
+	tearDownSSH()
+}
+
+func TestIloGetBootMode(t *testing.T) {
+	expectedAnswer := devices.BootModeLegacy
+
+	bmc, err := setupSSH()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+
+	answer, err := bmc.GetBootMode()
+	if err != nil {
+		t.Fatalf("Found errors calling bmc.GetBootMode %v", err)
+	}
+
+	if answer != expectedAnswer {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, answer)
+	}
+
+	tearDownSSH()
+}
+
+func TestIloSetBootMode(t *testing.T) {
+	expectedAnswer := true
+
+	bmc, err := setupSSH()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+
+	answer, err := bmc.SetBootMode(devices.BootModeUEFI)
+	if err != nil {
+		t.Fatalf("Found errors calling bmc.SetBootMode %v", err)
+	}
+
+	if answer != expectedAnswer {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, answer)
+	}
+
+	tearDownSSH()
+}
+
+func TestIloRecoveryCounters(t *testing.T) {
+	expectedAnswer := devices.RecoveryStats{
+		ASRCount: 2,
+		LastASR:  time.Date(2019, 3, 12, 10, 42, 7, 0, time.UTC),
+		NMICount: 1,
+	}
+
+	bmc, err := setupSSH()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+	defer tearDownSSH()
+
+	answer, err := bmc.RecoveryCounters()
+	if err != nil {
+		t.Fatalf("Found errors calling bmc.RecoveryCounters %v", err)
+	}
+
+	if answer != expectedAnswer {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, answer)
+	}
+}
+
+func TestIloResetRecoveryCounters(t *testing.T) {
+	expectedAnswer := true
+
+	bmc, err := setupSSH()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+	defer tearDownSSH()
+
+	answer, err := bmc.ResetRecoveryCounters()
+	if err != nil {
+		t.Fatalf("Found errors calling bmc.ResetRecoveryCounters %v", err)
+	}
+
+	if answer != expectedAnswer {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, answer)
+	}
+}
+
+func TestIloParseASRStatus(t *testing.T) {
+	tt := []struct {
+		properties map[string]string
+		expected   devices.RecoveryStats
+		fails      bool
+	}{
+		{
+			properties: map[string]string{"oemhp_asr_count": "0", "oemhp_asr_last": "None", "oemhp_nmi_count": "0"},
+		},
+		{
+			properties: map[string]string{"oemhp_asr_count": "1", "oemhp_asr_last": "11/15/2017 18:49:59", "oemhp_nmi_count": "3"},
+			expected:   devices.RecoveryStats{ASRCount: 1, LastASR: time.Date(2017, 11, 15, 18, 49, 59, 0, time.UTC), NMICount: 3},
+		},
+		{
+			properties: map[string]string{"oemhp_asr_status": "enabled"},
+			fails:      true,
+		},
+	}
+
+	for _, tc := range tt {
+		answer, err := parseASRStatus(tc.properties)
+		if (err != nil) != tc.fails {
+			t.Errorf("%v: Found unexpected error %v", tc.properties, err)
+		}
+
+		if !tc.fails && answer != tc.expected {
+			t.Errorf("Expected answer %v: found %v", tc.expected, answer)
+		}
+	}
+}
+
 func TestIloIsOn(t *testing.T) {
 	expectedAnswer := true
 
@@ -265,3 +954,191 @@ func TestIloIsOn(t *testing.T) {
 		t.Errorf("Expected answer %v: found %v", expectedAnswer, answer)
 	}
 }
+
+func TestIloVirtualMediaStatus(t *testing.T) {
+	expectedAnswer := []devices.VirtualMediaDevice{
+		{Type: devices.VirtualMediaFloppy, Attached: false},
+		{Type: devices.VirtualMediaCD, Attached: true, ImageURL: "http://images.example.com/rescue.iso"},
+	}
+
+	bmc, err := setupSSH()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+
+	answer, err := bmc.VirtualMediaStatus()
+	if err != nil {
+		t.Fatalf("Found errors calling bmc.VirtualMediaStatus %v", err)
+	}
+
+	if !reflect.DeepEqual(answer, expectedAnswer) {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, answer)
+	}
+
+	tearDownSSH()
+}
+
+func TestIloServiceTag(t *testing.T) {
+	expectedAnswer := "CZ3521YAEK"
+
+	bmc, err := setupSSH()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+
+	answer, err := bmc.ServiceTag()
+	if err != nil {
+		t.Fatalf("Found errors calling bmc.ServiceTag %v", err)
+	}
+
+	if answer != expectedAnswer {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, answer)
+	}
+
+	tearDownSSH()
+}
+
+func TestIloPowerState(t *testing.T) {
+	tt := []struct {
+		output   string
+		expected string
+	}{
+		{"power: server power is currently: On", devices.PowerStateOn},
+		{"power: server power is currently: Off", devices.PowerStateOff},
+		{"\r\n  power: server power is currently: On  \r\n", devices.PowerStateOn},
+		{"power: server power is currently: Off\r\n\r\n", devices.PowerStateOff},
+		{"status=2\r\nstatus_tag=COMMAND PROCESSING FAILED\r\n", devices.PowerStateUnknown},
+	}
+
+	bmc, err := setupSSH()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+	defer tearDownSSH()
+
+	power := sshAnswers["power"]
+	defer func() { sshAnswers["power"] = power }()
+
+	for _, tc := range tt {
+		sshAnswers["power"] = []byte(tc.output)
+
+		answer, err := bmc.PowerState()
+		if answer != tc.expected {
+			t.Errorf("%q: Expected answer %v: found %v", tc.output, tc.expected, answer)
+		}
+		if (err != nil) != (tc.expected == devices.PowerStateUnknown) {
+			t.Errorf("%q: Found unexpected error calling bmc.PowerState %v", tc.output, err)
+		}
+	}
+}
+
+func TestIloIsReachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+	closed := listener.Addr().String()
+	listener.Close()
+
+	// New would fetch the inventory over http
+	unreachable := &Ilo{ip: closed, username: "super", password: "test"}
+	if unreachable.IsReachable() {
+		t.Errorf("Expected answer %v for the closed port %s: found %v", false, closed, true)
+	}
+
+	bmc, err := setupSSH()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+	defer tearDownSSH()
+
+	if !bmc.IsReachable() {
+		t.Errorf("Expected answer %v: found %v", true, false)
+	}
+}
+
+func TestIloParsePowerDetail(t *testing.T) {
+	tt := []struct {
+		name   string
+		output string
+		detail PowerDetail
+	}{
+		{
+			name: "command",
+			output: `status=0
+status_tag=COMMAND COMPLETED
+Tue Feb 13 10:02:48 2018
+
+power: server power is currently: On
+
+`,
+			detail: PowerDetail{State: devices.PowerStateOn},
+		},
+		{
+			name: "banner",
+			output: "User:admin logged-in to ILOCZ3521YAEK.example.com(10.193.251.48 / FE80::9AF2:B3FF:FE2A:7C1E)\r\n" +
+				"iLO 4 Advanced 2.55 at  Nov 08 2017\r\n" +
+				"Server Name: bbmi.example.com\r\n" +
+				"Server Power: Off\r\n" +
+				"\r\n" +
+				"</>hpiLO-> power\r\n" +
+				"\r\n" +
+				"status=0\r\n" +
+				"status_tag=COMMAND COMPLETED\r\n" +
+				"\r\n" +
+				"power: server power is currently: On\r\n",
+			detail: PowerDetail{State: devices.PowerStateOn},
+		},
+		{
+			name: "banner only",
+			output: `User:admin logged-in to ILOCZ3521YAEK.example.com(10.193.251.48 / FE80::9AF2:B3FF:FE2A:7C1E)
+iLO 4 Advanced 2.55 at  Nov 08 2017
+Server Name: bbmi.example.com
+Server Power: Off
+`,
+			detail: PowerDetail{State: devices.PowerStateOff},
+		},
+		{
+			name: "button press",
+			output: `status=0
+status_tag=COMMAND COMPLETED
+Tue Feb 13 10:02:48 2018
+
+power: Press and hold sent to the server.
+power: server power is currently: Off
+`,
+			detail: PowerDetail{State: devices.PowerStateOff, LastEvent: "power: Press and hold sent to the server."},
+		},
+		{
+			name:   "failure",
+			output: "status=2\r\nstatus_tag=COMMAND PROCESSING FAILED\r\n",
+			detail: PowerDetail{State: devices.PowerStateUnknown},
+		},
+	}
+
+	for _, tc := range tt {
+		detail := parsePowerDetail(tc.output)
+		if detail != tc.detail {
+			t.Errorf("%s: Expected answer %v: found %v", tc.name, tc.detail, detail)
+		}
+	}
+}
+
+func TestIloPowerDetail(t *testing.T) {
+	expectedAnswer := devices.PowerStateOn
+
+	bmc, err := setupSSH()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+	defer tearDownSSH()
+
+	answer, err := bmc.PowerDetail()
+	if err != nil {
+		t.Fatalf("Found errors calling bmc.PowerDetail %v", err)
+	}
+
+	if answer.State != expectedAnswer {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, answer.State)
+	}
+}
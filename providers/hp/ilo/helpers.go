@@ -0,0 +1,222 @@
+package ilo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bmc-toolbox/bmclib/devices"
+	"github.com/bmc-toolbox/bmclib/internal/sshclient"
+	"golang.org/x/crypto/ssh"
+)
+
+// parsePowerState normalizes the state printed by the power command, e.g.
+// power: server power is currently: On
+func parsePowerState(output string) (state string) {
+	output = strings.TrimSpace(output)
+	if idx := strings.LastIndex(output, ":"); idx != -1 {
+		output = output[idx+1:]
+	}
+
+	switch strings.ToLower(strings.TrimSpace(output)) {
+	case "on":
+		return devices.PowerStateOn
+	case "off":
+		return devices.PowerStateOff
+	}
+
+	return devices.PowerStateUnknown
+}
+
+// parsePowerDetail reads the output of the power command line by line, skipping the status lines and
+// the banner printed by the shell. The state is taken from the power line or, without it, from the
+// Server Power line of the banner
+//
+// power: server power is currently: On
+// Server Power: On
+func parsePowerDetail(output string) (detail PowerDetail) {
+	detail.State = devices.PowerStateUnknown
+	banner := devices.PowerStateUnknown
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		lower := strings.ToLower(line)
+
+		switch {
+		case strings.Contains(lower, "power is currently:"):
+			detail.State = parsePowerState(line)
+		case strings.HasPrefix(lower, "server power:"):
+			banner = parsePowerState(line)
+		case strings.Contains(lower, "momentary press"), strings.Contains(lower, "press and hold"):
+			detail.LastEvent = line
+		}
+	}
+
+	if detail.State == devices.PowerStateUnknown {
+		detail.State = banner
+	}
+
+	return detail
+}
+
+// parseShow parses the output of the SMASH CLP show command into a tree of targets,
+// when called with -a every target is printed and nested under the first one
+//
+// /system1
+//
+//	Targets
+//	  sensor1
+//	Properties
+//	  name=ProLiant DL360 Gen9
+//	Verbs
+//	  cd version exit show reset start stop set
+func parseShow(output string) (root *CLPTarget, err error) {
+	targets := make(map[string]*CLPTarget)
+	var current *CLPTarget
+	var section string
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "/") {
+			current = targets[trimmed]
+			if current == nil {
+				current = &CLPTarget{Path: trimmed}
+				targets[trimmed] = current
+			}
+			if root == nil {
+				root = current
+			}
+			current.Properties = make(map[string]string)
+			section = ""
+			continue
+		}
+
+		// status=0, status_tag=COMMAND COMPLETED and the timestamp come before the first target
+		if current == nil {
+			if strings.HasPrefix(trimmed, "status=") && trimmed != "status=0" {
+				return root, fmt.Errorf("show failed: %s", output)
+			}
+			continue
+		}
+
+		switch trimmed {
+		case "Targets", "Properties", "Verbs":
+			section = trimmed
+			continue
+		}
+
+		switch section {
+		case "Targets":
+			path := fmt.Sprintf("%s/%s", strings.TrimRight(current.Path, "/"), trimmed)
+			child := targets[path]
+			if child == nil {
+				child = &CLPTarget{Path: path}
+				targets[path] = child
+			}
+			current.Targets = append(current.Targets, child)
+		case "Properties":
+			data := strings.SplitN(trimmed, "=", 2)
+			if len(data) == 2 {
+				current.Properties[data[0]] = data[1]
+			}
+		case "Verbs":
+			current.Verbs = append(current.Verbs, strings.Fields(trimmed)...)
+		}
+	}
+
+	if root == nil {
+		return root, fmt.Errorf("unable to find any target in: %s", output)
+	}
+
+	return root, err
+}
+
+// asrTarget holds the automatic server recovery status of the server
+const asrTarget = "/system1/oemhp_asr1"
+
+// asrTimeFormat is the format of the last ASR reboot, None when the server never went through one
+const asrTimeFormat = "01/02/2006 15:04:05"
+
+// parseASRStatus reads the counters of the automatic server recovery status
+//
+// oemhp_asr_count=2
+// oemhp_asr_last=03/12/2019 10:42:07
+// oemhp_nmi_count=1
+func parseASRStatus(properties map[string]string) (stats devices.RecoveryStats, err error) {
+	stats.ASRCount, err = strconv.Atoi(properties["oemhp_asr_count"])
+	if err != nil {
+		return stats, fmt.Errorf("unable to read the ASR count: %v", err)
+	}
+
+	stats.NMICount, err = strconv.Atoi(properties["oemhp_nmi_count"])
+	if err != nil {
+		return stats, fmt.Errorf("unable to read the NMI count: %v", err)
+	}
+
+	if last := properties["oemhp_asr_last"]; last != "" && last != "None" {
+		stats.LastASR, err = time.Parse(asrTimeFormat, last)
+		if err != nil {
+			return stats, fmt.Errorf("unable to read the last ASR reboot: %v", err)
+		}
+	}
+
+	return stats, err
+}
+
+// healthRanks orders the HealthState values of the iLO sensors from the best to the worst
+var healthRanks = map[string]int{
+	"Ok":       0,
+	"Degraded": 1,
+	"Critical": 2,
+}
+
+// userPrivileges lists the groups the iLO cli grants to the user accounts
+var userPrivileges = map[string]bool{
+	"admin":       true, // administer the user accounts
+	"config":      true, // configure the iLO settings
+	"oemhp_rc":    true, // remote console
+	"oemhp_power": true, // virtual power and reset
+	"oemhp_vm":    true, // virtual media
+}
+
+// validatePrivileges makes sure the iLO knows every one of the given privileges
+func validatePrivileges(privileges []string) (err error) {
+	for _, privilege := range privileges {
+		if !userPrivileges[privilege] {
+			return fmt.Errorf("invalid privilege: %q", privilege)
+		}
+	}
+
+	return err
+}
+
+// sessionDropped tells if the command failed because the iLO closed the ssh session under it,
+// as it does when it resets itself. A session that couldn't be opened never got the command
+func sessionDropped(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if _, notSent := err.(*sshclient.SessionError); notSent {
+		return false
+	}
+
+	if _, missing := err.(*ssh.ExitMissingError); missing {
+		return true
+	}
+
+	reason := strings.ToLower(err.Error())
+	for _, dropped := range []string{"eof", "connection reset", "broken pipe"} {
+		if strings.Contains(reason, dropped) {
+			return true
+		}
+	}
+
+	return false
+}
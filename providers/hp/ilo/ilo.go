@@ -2,6 +2,7 @@ package ilo
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
@@ -13,8 +14,10 @@ import (
 
 	"github.com/bmc-toolbox/bmclib/devices"
 	"github.com/bmc-toolbox/bmclib/errors"
+	"github.com/bmc-toolbox/bmclib/internal/helper"
 	"github.com/bmc-toolbox/bmclib/internal/httpclient"
 	"github.com/bmc-toolbox/bmclib/internal/sshclient"
+	"github.com/bmc-toolbox/bmclib/internal/tracing"
 	"github.com/bmc-toolbox/bmclib/providers/hp"
 
 	// this make possible to setup logging and properties at any stage
@@ -38,17 +41,25 @@ const (
 
 // Ilo holds the status and properties of a connection to an iLO device
 type Ilo struct {
-	ip         string
-	username   string
-	password   string
-	sessionKey string
-	httpClient *http.Client
-	sshClient  *sshclient.SSHClient
-	serial     string
-	loginURL   *url.URL
-	rimpBlade  *hp.RimpBlade
+	ip          string
+	username    string
+	password    string
+	sessionKey  string
+	httpClient  *http.Client
+	sshClient   *sshclient.SSHClient
+	sshOptions  sshclient.Options
+	manualLogin bool
+	traceCtx    context.Context
+	serial      string
+	loginURL    *url.URL
+	rimpBlade   *hp.RimpBlade
 }
 
+var (
+	_ devices.PowerController = (*Ilo)(nil)
+	_ devices.Inventory       = (*Ilo)(nil)
+)
+
 // New returns a new Ilo ready to be used
 func New(ip string, username string, password string) (ilo *Ilo, err error) {
 	loginURL, err := url.Parse(fmt.Sprintf("https://%s/json/login_session", ip))
@@ -56,35 +67,48 @@ func New(ip string, username string, password string) (ilo *Ilo, err error) {
 		return nil, err
 	}
 
-	client, err := httpclient.Build()
+	rimpBlade, err := loadRimpBlade(ip)
 	if err != nil {
 		return ilo, err
 	}
 
+	return &Ilo{ip: ip, username: username, password: password, loginURL: loginURL, rimpBlade: rimpBlade}, err
+}
+
+// loadRimpBlade reads the xmldata the iLO publishes without authentication, it tells the iLO generation and firmware
+func loadRimpBlade(ip string) (rimpBlade *hp.RimpBlade, err error) {
+	client, err := httpclient.Build()
+	if err != nil {
+		return rimpBlade, err
+	}
+
 	xmlURL := fmt.Sprintf("https://%s/xmldata?item=all", ip)
 	resp, err := client.Get(xmlURL)
 	if err != nil {
-		return ilo, err
+		return rimpBlade, err
 	}
 
 	payload, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return ilo, err
+		return rimpBlade, err
 	}
 	defer resp.Body.Close()
 
-	rimpBlade := &hp.RimpBlade{}
+	rimpBlade = &hp.RimpBlade{}
 	err = xml.Unmarshal(payload, rimpBlade)
 	if err != nil {
 		httpclient.DumpInvalidPayload(xmlURL, ip, payload)
-		return ilo, err
+		return rimpBlade, err
 	}
 
-	return &Ilo{ip: ip, username: username, password: password, loginURL: loginURL, rimpBlade: rimpBlade}, err
+	return rimpBlade, err
 }
 
 // CheckCredentials verify whether the credentials are valid or not
 func (i *Ilo) CheckCredentials() (err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "CheckCredentials", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return err
@@ -203,11 +227,17 @@ func (i *Ilo) post(endpoint string, data []byte) (statusCode int, body []byte, e
 
 // Serial returns the device serial
 func (i *Ilo) Serial() (serial string, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "Serial", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	return strings.ToLower(strings.TrimSpace(i.rimpBlade.HSI.Sbsn)), err
 }
 
 // Model returns the device model
 func (i *Ilo) Model() (model string, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "Model", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	return i.rimpBlade.HSI.Spn, err
 }
 
@@ -229,11 +259,17 @@ func (i *Ilo) BmcType() (bmcType string) {
 
 // BmcVersion returns the version of the bmc we are running
 func (i *Ilo) BmcVersion() (bmcVersion string, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "BmcVersion", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	return i.rimpBlade.MP.Fwri, err
 }
 
 // Name returns the name of this server from the iLO point of view
 func (i *Ilo) Name() (name string, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "Name", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return name, err
@@ -257,6 +293,9 @@ func (i *Ilo) Name() (name string, err error) {
 
 // Status returns health string status from the bmc
 func (i *Ilo) Status() (health string, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "Status", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return health, err
@@ -284,6 +323,9 @@ func (i *Ilo) Status() (health string, err error) {
 
 // Memory returns the total amount of memory of the server
 func (i *Ilo) Memory() (mem int, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "Memory", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return mem, err
@@ -315,6 +357,9 @@ func (i *Ilo) Memory() (mem int, err error) {
 
 // CPU returns the cpu, cores and hyperthreads of the server
 func (i *Ilo) CPU() (cpu string, cpuCount int, coreCount int, hyperthreadCount int, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "CPU", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return cpu, cpuCount, coreCount, hyperthreadCount, err
@@ -342,6 +387,9 @@ func (i *Ilo) CPU() (cpu string, cpuCount int, coreCount int, hyperthreadCount i
 
 // BiosVersion returns the current version of the bios
 func (i *Ilo) BiosVersion() (version string, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "BiosVersion", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return version, err
@@ -369,6 +417,9 @@ func (i *Ilo) BiosVersion() (version string, err error) {
 
 // PowerKw returns the current power usage in Kw
 func (i *Ilo) PowerKw() (power float64, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "PowerKw", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return power, err
@@ -390,8 +441,62 @@ func (i *Ilo) PowerKw() (power float64, err error) {
 	return float64(hpPowerSummary.PowerSupplyInputPower) / 1024, err
 }
 
-// PowerState returns the current power state of the machine
+// PowerState returns the current power state of the machine as printed by the power command
+// of the ssh cli, one of devices.PowerStateOn, devices.PowerStateOff or devices.PowerStateUnknown
 func (i *Ilo) PowerState() (state string, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "PowerState", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return state, err
+	}
+
+	output, err := i.run("power")
+	if err != nil {
+		return devices.PowerStateUnknown, fmt.Errorf("%v: %v", err, output)
+	}
+
+	state = parsePowerState(output)
+	if state == devices.PowerStateUnknown {
+		return state, fmt.Errorf("unknown power state: %s", strings.TrimSpace(output))
+	}
+
+	return state, err
+}
+
+// PowerDetail returns the power state of the machine as PowerState does, along with the last press of
+// the power button when the power command reports it and the auto power-on policy read over ipmi
+func (i *Ilo) PowerDetail() (detail PowerDetail, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "PowerDetail", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.sshLogin()
+	if err != nil {
+		return detail, err
+	}
+
+	output, err := i.run("power")
+	if err != nil {
+		return detail, fmt.Errorf("%v: %v", err, output)
+	}
+
+	detail = parsePowerDetail(output)
+	if detail.State == devices.PowerStateUnknown {
+		return detail, fmt.Errorf("unknown power state: %s", strings.TrimSpace(output))
+	}
+
+	// the policy is only known when the bmc answers over ipmi
+	policy, e := i.GetPowerRestorePolicy()
+	if e == nil {
+		detail.AutoPowerOn = policy
+	}
+
+	return detail, err
+}
+
+// powerSummaryState returns the power state of the machine reported by the web interface
+func (i *Ilo) powerSummaryState() (state string, err error) {
 	err = i.httpLogin()
 	if err != nil {
 		return state, err
@@ -415,6 +520,9 @@ func (i *Ilo) PowerState() (state string, err error) {
 
 // TempC returns the current temperature of the machine
 func (i *Ilo) TempC() (temp int, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "TempC", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return temp, err
@@ -444,6 +552,9 @@ func (i *Ilo) TempC() (temp int, err error) {
 
 // Nics returns all found Nics in the device
 func (i *Ilo) Nics() (nics []*devices.Nic, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "Nics", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	if i.rimpBlade.HSI != nil && i.rimpBlade.HSI.NICS != nil {
 		for _, nic := range i.rimpBlade.HSI.NICS {
 			var name string
@@ -469,6 +580,9 @@ func (i *Ilo) Nics() (nics []*devices.Nic, err error) {
 
 // License returns the iLO's license information
 func (i *Ilo) License() (name string, licType string, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "License", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return name, licType, err
@@ -492,6 +606,9 @@ func (i *Ilo) License() (name string, licType string, err error) {
 
 // Psus returns a list of psus installed on the device
 func (i *Ilo) Psus() (psus []*devices.Psu, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "Psus", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return psus, err
@@ -534,8 +651,62 @@ func (i *Ilo) Psus() (psus []*devices.Psu, err error) {
 	return psus, err
 }
 
+// PSUs returns the detailed status of the power supplies, the iLO doesn't report the redundancy
+// so a power supply is considered redundant when at least another healthy one is present
+func (i *Ilo) PSUs() (psus []*devices.PowerSupply, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "PSUs", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	err = i.httpLogin()
+	if err != nil {
+		return psus, err
+	}
+
+	url := "json/power_supplies"
+	payload, err := i.get(url)
+	if err != nil {
+		return psus, err
+	}
+
+	hpIloPowerSupply := &hp.IloPowerSupply{}
+	err = json.Unmarshal(payload, hpIloPowerSupply)
+	if err != nil {
+		httpclient.DumpInvalidPayload(url, i.ip, payload)
+		return psus, err
+	}
+
+	var healthy int
+	for _, psu := range hpIloPowerSupply.Supplies {
+		if psu.PsPresent == "PS_YES" && psu.PsCondition == "PS_OK" {
+			healthy++
+		}
+	}
+
+	for _, psu := range hpIloPowerSupply.Supplies {
+		status := psu.PsCondition
+		if psu.PsCondition == "PS_OK" {
+			status = "OK"
+		}
+
+		psus = append(psus, &devices.PowerSupply{
+			ID:           fmt.Sprintf("PS%d", psu.PsBay),
+			Model:        psu.PsModel,
+			Status:       status,
+			OutputWatts:  psu.PsOutputWatts,
+			InputVoltage: psu.PsInputVolts,
+			Redundant:    status == "OK" && healthy > 1,
+			Present:      psu.PsPresent == "PS_YES",
+		})
+	}
+
+	return psus, err
+}
+
 // Disks returns a list of disks installed on the device
 func (i *Ilo) Disks() (disks []*devices.Disk, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "Disks", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return disks, err
@@ -594,6 +765,9 @@ func (i *Ilo) Disks() (disks []*devices.Disk, err error) {
 
 // IsBlade returns if the current hardware is a blade or not
 func (i *Ilo) IsBlade() (isBlade bool, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "IsBlade", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	if i.rimpBlade.BladeSystem != nil {
 		isBlade = true
 	} else {
@@ -608,8 +782,16 @@ func (i *Ilo) Vendor() (vendor string) {
 	return hp.VendorID
 }
 
+// SupportedActions returns the names of the action methods implemented by this provider
+func (i *Ilo) SupportedActions() (actions []string) {
+	return helper.SupportedActions(i)
+}
+
 // ServerSnapshot do best effort to populate the server data and returns a blade or discrete
 func (i *Ilo) ServerSnapshot() (server interface{}, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "ServerSnapshot", i.ip)
+	defer func() { tracing.End(span, err) }()
+
 	err = i.httpLogin()
 	if err != nil {
 		return server, err
@@ -669,7 +851,7 @@ func (i *Ilo) ServerSnapshot() (server interface{}, err error) {
 		if err != nil {
 			return nil, err
 		}
-		blade.PowerState, err = i.PowerState()
+		blade.PowerState, err = i.powerSummaryState()
 		if err != nil {
 			return nil, err
 		}
@@ -736,7 +918,7 @@ func (i *Ilo) ServerSnapshot() (server interface{}, err error) {
 		if err != nil {
 			return nil, err
 		}
-		discrete.PowerState, err = i.PowerState()
+		discrete.PowerState, err = i.powerSummaryState()
 		if err != nil {
 			return nil, err
 		}
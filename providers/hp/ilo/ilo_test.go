@@ -1,6 +1,7 @@
 package ilo
 
 import (
+	"bytes"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -525,6 +526,35 @@ func TestIloPsu(t *testing.T) {
 	tearDown()
 }
 
+func TestIloPSUs(t *testing.T) {
+	expectedAnswer := []*devices.PowerSupply{
+		&devices.PowerSupply{ID: "PS1", Model: "720478-B21", Status: "OK", OutputWatts: 73, InputVoltage: 230, Redundant: true, Present: true},
+		&devices.PowerSupply{ID: "PS2", Model: "720478-B21", Status: "OK", OutputWatts: 70, InputVoltage: 228, Redundant: true, Present: true},
+	}
+
+	bmc, err := setup()
+	if err != nil {
+		t.Fatalf("Found errors during the test discrete %v", err)
+	}
+
+	psus, err := bmc.PSUs()
+	if err != nil {
+		t.Fatalf("Found errors calling discrete.PSUs %v", err)
+	}
+
+	if len(psus) != len(expectedAnswer) {
+		t.Fatalf("Expected %v psus: found %v psus", len(expectedAnswer), len(psus))
+	}
+
+	for pos, psu := range psus {
+		if *psu != *expectedAnswer[pos] {
+			t.Errorf("Expected answer %v: found %v", expectedAnswer[pos], psu)
+		}
+	}
+
+	tearDown()
+}
+
 func TestIloDisks(t *testing.T) {
 	expectedAnswer := []*devices.Disk{
 		&devices.Disk{
@@ -604,9 +634,9 @@ func TestIloPoweState(t *testing.T) {
 		t.Fatalf("Found errors during the test setup %v", err)
 	}
 
-	answer, err := bmc.PowerState()
+	answer, err := bmc.powerSummaryState()
 	if err != nil {
-		t.Fatalf("Found errors calling bmc.PowerState %v", err)
+		t.Fatalf("Found errors calling bmc.powerSummaryState %v", err)
 	}
 
 	if expectedAnswer != answer {
@@ -643,3 +673,27 @@ func TestUpdateCredentials(t *testing.T) {
 
 	tearDown()
 }
+
+func TestIloRefresh(t *testing.T) {
+	expectedAnswer := "ilo5"
+
+	bmc, err := setup()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+	defer tearDown()
+
+	xmldata := Answers["/xmldata"]
+	Answers["/xmldata"] = bytes.Replace(xmldata, []byte("Integrated Lights-Out 4 (iLO 4)"), []byte("Integrated Lights-Out 5 (iLO 5)"), 1)
+	defer func() { Answers["/xmldata"] = xmldata }()
+
+	err = bmc.Refresh()
+	if err != nil {
+		t.Fatalf("Found errors calling bmc.Refresh %v", err)
+	}
+
+	answer := bmc.BmcType()
+	if answer != expectedAnswer {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, answer)
+	}
+}
@@ -1,5 +1,7 @@
 package ilo
 
+import "github.com/bmc-toolbox/bmclib/devices"
+
 type Users struct {
 	UsersInfo []UserInfo `json:"users"`
 }
@@ -101,6 +103,28 @@ type DirectoryGroups struct {
 }
 
 //Important timezone ints taken from https://10.193.251.48/html/network_sntp.html?intf=0
+// CLPTarget is a target of the SMASH CLP tree as printed by show
+type CLPTarget struct {
+	Path       string
+	Properties map[string]string
+	Targets    []*CLPTarget
+	Verbs      []string
+}
+
+// DeviceInfo holds the server details exposed by /system1
+type DeviceInfo struct {
+	Model      string `json:"model"`
+	Serial     string `json:"serial"`
+	Hostname   string `json:"hostname"`
+	PowerState string `json:"power_state"`
+}
+
+// Health holds the overall health of the server and the health of each of its sensors
+type Health struct {
+	Overall string            `json:"overall"`
+	Sensors map[string]string `json:"sensors"`
+}
+
 var Timezones = map[string]int{
 	"CET":           368,
 	"CST6CDT":       371,
@@ -156,3 +180,12 @@ var Timezones = map[string]int{
 	"UTC":           562,
 	"WET":           564,
 }
+
+// PowerDetail holds the power state of the server along with what the bmc tells about it, LastEvent
+// is the power button press reported by the power command, e.g. Momentary press, and AutoPowerOn the
+// policy applied after an AC loss. Both are empty when the bmc doesn't report them
+type PowerDetail struct {
+	State       string                     `json:"state"`
+	AutoPowerOn devices.PowerRestorePolicy `json:"auto_power_on"`
+	LastEvent   string                     `json:"last_event"`
+}
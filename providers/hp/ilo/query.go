@@ -2,15 +2,22 @@ package ilo
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
 
+	"github.com/bmc-toolbox/bmclib/devices"
 	"github.com/bmc-toolbox/bmclib/errors"
 	"github.com/bmc-toolbox/bmclib/internal/helper"
+	"github.com/bmc-toolbox/bmclib/internal/tracing"
+	"github.com/bmc-toolbox/bmclib/providers/hp"
 
 	log "github.com/sirupsen/logrus"
 )
 
 // Screenshot returns a thumbnail of video display from the bmc.
 func (i *Ilo) Screenshot() (response []byte, extension string, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "Screenshot", i.ip)
+	defer func() { tracing.End(span, err) }()
 
 	endpoint := "images/thumbnail.bmp"
 	extension = "bmp"
@@ -122,3 +129,153 @@ func (i *Ilo) queryNetworkSntp() (networkSntp NetworkSntp, err error) {
 
 	return networkSntp, err
 }
+
+// show runs the SMASH CLP show command for the given target and parses its output
+func (i *Ilo) show(target string) (clpTarget *CLPTarget, err error) {
+	err = i.sshLogin()
+	if err != nil {
+		return clpTarget, err
+	}
+
+	output, err := i.run(fmt.Sprintf("show %s", target))
+	if err != nil {
+		return clpTarget, fmt.Errorf("show %s failed: %s", target, output)
+	}
+
+	return parseShow(output)
+}
+
+// VirtualMediaStatus returns the virtual media drives listed under /map1/oemhp_vm1 and the image they have attached
+func (i *Ilo) VirtualMediaStatus() (vmedia []devices.VirtualMediaDevice, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "VirtualMediaStatus", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	vm, err := i.show("/map1/oemhp_vm1")
+	if err != nil {
+		return vmedia, err
+	}
+
+	for _, drive := range vm.Targets {
+		drive, err = i.show(drive.Path)
+		if err != nil {
+			return vmedia, err
+		}
+
+		device := devices.VirtualMediaDevice{Attached: strings.EqualFold(drive.Properties["oemhp_connect"], "yes")}
+		name := drive.Path[strings.LastIndex(drive.Path, "/")+1:]
+		switch {
+		case strings.HasPrefix(name, "cd"):
+			device.Type = devices.VirtualMediaCD
+		case strings.HasPrefix(name, "floppy"):
+			device.Type = devices.VirtualMediaFloppy
+		case strings.HasPrefix(name, "usb"):
+			device.Type = devices.VirtualMediaUSB
+		default:
+			continue
+		}
+
+		if image := drive.Properties["oemhp_image"]; image != "None" {
+			device.ImageURL = image
+		}
+
+		vmedia = append(vmedia, device)
+	}
+
+	return vmedia, err
+}
+
+// ServiceTag returns the serial number of the server as exposed by /system1, upper case as printed on the pull tab
+func (i *Ilo) ServiceTag() (serviceTag string, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "ServiceTag", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	system, err := i.show("/system1")
+	if err != nil {
+		return serviceTag, err
+	}
+
+	serviceTag = strings.ToUpper(strings.TrimSpace(system.Properties["number"]))
+	if serviceTag == "" {
+		return serviceTag, errors.ErrInvalidSerial
+	}
+
+	return serviceTag, err
+}
+
+// DeviceInfo returns the server details exposed by the SMASH CLP /system1 target
+func (i *Ilo) DeviceInfo() (info DeviceInfo, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "DeviceInfo", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	system, err := i.show("/system1")
+	if err != nil {
+		return info, err
+	}
+
+	info.Model = system.Properties["name"]
+	info.Serial = strings.ToLower(system.Properties["number"])
+	info.Hostname = system.Properties["oemhp_server_name"]
+
+	switch system.Properties["enabled_state"] {
+	case "enabled":
+		info.PowerState = "on"
+	case "disabled":
+		info.PowerState = "off"
+	default:
+		info.PowerState = system.Properties["enabled_state"]
+	}
+
+	return info, err
+}
+
+// Health returns the health reported by each sensor of /system1, the overall health is the worst of them
+func (i *Ilo) Health() (health Health, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "Health", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	system, err := i.show("/system1")
+	if err != nil {
+		return health, err
+	}
+
+	health.Overall = "Ok"
+	health.Sensors = make(map[string]string)
+	for _, target := range system.Targets {
+		if !strings.HasPrefix(target.Path, "/system1/sensor") {
+			continue
+		}
+
+		sensor, err := i.show(target.Path)
+		if err != nil {
+			return health, err
+		}
+
+		state := sensor.Properties["HealthState"]
+		health.Sensors[sensor.Properties["ElementName"]] = state
+		if healthRanks[state] > healthRanks[health.Overall] {
+			health.Overall = state
+		}
+	}
+
+	return health, err
+}
+
+// GetBootMode returns the boot mode the server is currently using
+func (i *Ilo) GetBootMode() (mode devices.BootMode, err error) {
+	span := tracing.Start(i.traceCtx, hp.VendorID, "GetBootMode", i.ip)
+	defer func() { tracing.End(span, err) }()
+
+	bootconfig, err := i.show("/system1/bootconfig1")
+	if err != nil {
+		return mode, err
+	}
+
+	switch strings.ToLower(bootconfig.Properties["oemhp_bootmode"]) {
+	case "uefi":
+		return devices.BootModeUEFI, err
+	case "legacy":
+		return devices.BootModeLegacy, err
+	}
+
+	return mode, fmt.Errorf("unknown boot mode: %v", bootconfig.Properties)
+}
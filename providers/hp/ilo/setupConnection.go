@@ -2,6 +2,7 @@ package ilo
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -9,7 +10,9 @@ import (
 	"net/http/httputil"
 	"net/url"
 	"strings"
+	"time"
 
+	"github.com/bmc-toolbox/bmclib/devices"
 	"github.com/bmc-toolbox/bmclib/errors"
 	"github.com/bmc-toolbox/bmclib/internal/httpclient"
 	"github.com/bmc-toolbox/bmclib/internal/sshclient"
@@ -17,6 +20,7 @@ import (
 
 	multierror "github.com/hashicorp/go-multierror"
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
 )
 
 // Login initiates the connection to a bmc device
@@ -108,14 +112,28 @@ func (i *Ilo) httpLogin() (err error) {
 	return err
 }
 
-// Login initiates the connection to a bmc device
+// sshLogin initiates the connection to a bmc device
 func (i *Ilo) sshLogin() (err error) {
 	if i.sshClient != nil {
 		return
 	}
 
+	if i.manualLogin {
+		return errors.ErrNotLoggedIn
+	}
+
+	return i.Login()
+}
+
+// Login establishes the ssh session used by the actions, it's only required
+// to be called explicitly when the automatic login is disabled with SetAutoLogin
+func (i *Ilo) Login() (err error) {
+	if i.sshClient != nil {
+		return
+	}
+
 	log.WithFields(log.Fields{"step": "bmc connection", "vendor": hp.VendorID, "ip": i.ip}).Debug("connecting to bmc")
-	i.sshClient, err = sshclient.New(i.ip, i.username, i.password)
+	i.sshClient, err = sshclient.NewWithOptions(i.ip, i.username, i.password, i.sshOptions)
 	if err != nil {
 		return err
 	}
@@ -123,6 +141,116 @@ func (i *Ilo) sshLogin() (err error) {
 	return err
 }
 
+// run executes the command over ssh, the iLO drops the idle sessions so when the ssh session can't be
+// opened, the command never reached the iLO then, the connection is established again and the command
+// retried once. A login failing then is returned as is, a command failing once sent is never retried.
+// PowerCycleBmc doesn't go through it, the reset drops the session
+func (i *Ilo) run(command string) (output string, err error) {
+	output, err = i.sshClient.Run(command)
+	if _, notSent := err.(*sshclient.SessionError); !notSent {
+		return output, err
+	}
+
+	log.WithFields(log.Fields{"step": "bmc connection", "vendor": hp.VendorID, "ip": i.ip, "error": err}).Debug("ssh session dropped, reconnecting")
+	i.closeSSH()
+	err = i.sshLogin()
+	if err != nil {
+		return output, err
+	}
+
+	return i.sshClient.Run(command)
+}
+
+// closeSSH drops the ssh session once the iLO is known to have closed it, the next action connects again
+func (i *Ilo) closeSSH() {
+	if i.sshClient != nil {
+		i.sshClient.Close()
+		i.sshClient = nil
+	}
+}
+
+// IsReachable tells whether the bmc answers over ssh, connecting when there's no session and running
+// a command that changes nothing. The error is dropped, it's meant to skip the dead bmcs quickly
+func (i *Ilo) IsReachable() bool {
+	err := i.sshLogin()
+	if err != nil {
+		return false
+	}
+
+	_, err = i.sshClient.Run("power")
+	return err == nil
+}
+
+// SetAutoLogin defines whether the actions should establish the ssh session on demand,
+// when disabled the caller owns the session lifecycle via Login and Close
+func (i *Ilo) SetAutoLogin(enable bool) {
+	i.manualLogin = !enable
+}
+
+// SetOutputEncoding defines how the ssh command output is normalized before being parsed,
+// use devices.EncodingUTF8 or devices.EncodingLatin1 when the bmc prints non utf-8 data
+func (i *Ilo) SetOutputEncoding(encoding string) {
+	i.sshOptions.Encoding = encoding
+}
+
+// SetMaxOutputSize overrides the maximum number of bytes read from the output of a ssh command,
+// the command is aborted with a *errors.OutputTooLargeError past it. It's a few MB by default
+func (i *Ilo) SetMaxOutputSize(size int) {
+	i.sshOptions.MaxOutputSize = size
+}
+
+// SetSSHPort overrides the port used to connect over ssh, devices.SSHPort by default
+func (i *Ilo) SetSSHPort(port int) {
+	i.sshOptions.Port = port
+}
+
+// SetSSHTimeout overrides how long connecting over ssh may take, sshclient.DefaultTimeout by default.
+// Past it the login fails with an error wrapping errors.ErrConnectionTimeout
+func (i *Ilo) SetSSHTimeout(timeout time.Duration) {
+	i.sshOptions.Timeout = timeout
+}
+
+// SetSSHKey makes the ssh sessions established afterwards authenticate with the given key,
+// the password is only tried when the bmc rejects it
+func (i *Ilo) SetSSHKey(signer ssh.Signer) {
+	i.sshOptions.Signer = signer
+}
+
+// SetHostKeyCallback makes the ssh sessions established afterwards verify the host key of the bmc
+// with the given callback, e.g. one of golang.org/x/crypto/ssh/knownhosts. Any key is accepted by default
+func (i *Ilo) SetHostKeyCallback(callback ssh.HostKeyCallback) {
+	i.sshOptions.HostKeyCallback = callback
+}
+
+// SetLogger makes the ssh sessions established afterwards pass every command run and its output,
+// truncated and with the secrets masked, to the given logger. Nothing is logged by default
+func (i *Ilo) SetLogger(logger devices.Logger) {
+	i.sshOptions.Logger = logger
+}
+
+// SetSSHAlgorithms overrides the ciphers, key exchanges and macs offered during the ssh handshake,
+// old firmwares require devices.LegacySSHCiphers, devices.LegacySSHKeyExchanges and devices.LegacySSHMACs
+func (i *Ilo) SetSSHAlgorithms(ciphers []string, kex []string, macs []string) {
+	i.sshOptions.Ciphers = ciphers
+	i.sshOptions.KeyExchanges = kex
+	i.sshOptions.MACs = macs
+}
+
+// LastTimings returns how long the ssh connection took to dial and authenticate and how long
+// the last command took to run, Reused tells whether the command ran over an existing connection
+func (i *Ilo) LastTimings() (timings devices.Timings) {
+	if i.sshClient == nil {
+		return timings
+	}
+
+	return i.sshClient.Timings()
+}
+
+// SetTraceContext defines the context the OpenTelemetry spans of the actions are attached to
+func (i *Ilo) SetTraceContext(ctx context.Context) {
+	i.traceCtx = ctx
+}
+
 // Close closes the connection properly
 func (i *Ilo) Close() (err error) {
 	if i.httpClient != nil {
@@ -153,7 +281,29 @@ func (i *Ilo) Close() (err error) {
 		if e != nil {
 			err = multierror.Append(e, err)
 		}
+		i.sshClient = nil
+	}
+
+	return err
+}
+
+// Refresh drops the sessions and everything learnt about the iLO and detects it again. It should be
+// called once the iLO is back online after its firmware was updated or it was reset, otherwise
+// BmcType and BmcVersion keep reporting what the iLO was running when the provider was created
+func (i *Ilo) Refresh() (err error) {
+	// the sessions most likely died with the old firmware, failing to logout is expected
+	e := i.Close()
+	if e != nil {
+		log.WithFields(log.Fields{"step": "bmc connection", "vendor": hp.VendorID, "ip": i.ip, "error": e}).Debug("unable to close the previous sessions")
+	}
+	i.httpClient = nil
+	i.sessionKey = ""
+
+	rimpBlade, err := loadRimpBlade(i.ip)
+	if err != nil {
+		return err
 	}
+	i.rimpBlade = rimpBlade
 
 	return err
 }
@@ -1,6 +1,11 @@
 package supermicrox10
 
 import (
+	"context"
+	"io"
+
+	"github.com/bmc-toolbox/bmclib/devices"
+	"github.com/bmc-toolbox/bmclib/errors"
 	"github.com/bmc-toolbox/bmclib/internal/ipmi"
 )
 
@@ -14,6 +19,23 @@ func (s *SupermicroX10) PowerCycle() (status bool, err error) {
 	return status, err
 }
 
+// PowerCycleWith reboots the machine via bmc using the given reset type
+func (s *SupermicroX10) PowerCycleWith(resetType devices.ResetType) (status bool, err error) {
+	i, err := ipmi.New(s.username, s.password, s.ip)
+	if err != nil {
+		return status, err
+	}
+
+	switch resetType {
+	case devices.ResetWarm:
+		return i.PowerCycle()
+	case devices.ResetCold:
+		return i.PowerCycleCold()
+	}
+
+	return status, errors.ErrFeatureUnavailable
+}
+
 // PowerCycleBmc reboots the bmc we are connected to
 func (s *SupermicroX10) PowerCycleBmc() (status bool, err error) {
 	i, err := ipmi.New(s.username, s.password, s.ip)
@@ -44,6 +66,17 @@ func (s *SupermicroX10) PowerOff() (status bool, err error) {
 	return status, err
 }
 
+// PressPowerButton emulates a press of the power button, a held press forces the machine
+// off while a momentary press signals the operating system to shutdown
+func (s *SupermicroX10) PressPowerButton(hold bool) (status bool, err error) {
+	i, err := ipmi.New(s.username, s.password, s.ip)
+	if err != nil {
+		return status, err
+	}
+	status, err = i.PressPowerButton(hold)
+	return status, err
+}
+
 // PxeOnce makes the machine to boot via pxe once
 func (s *SupermicroX10) PxeOnce() (status bool, err error) {
 	i, err := ipmi.New(s.username, s.password, s.ip)
@@ -66,3 +99,62 @@ func (s *SupermicroX10) IsOn() (status bool, err error) {
 	status, err = i.IsOn()
 	return status, err
 }
+
+// RecoveryCounters returns the ASR and NMI events posted to the bmc
+func (s *SupermicroX10) RecoveryCounters() (stats devices.RecoveryStats, err error) {
+	i, err := ipmi.New(s.username, s.password, s.ip)
+	if err != nil {
+		return stats, err
+	}
+	stats, err = i.RecoveryCounters()
+	return stats, err
+}
+
+// ResetRecoveryCounters isn't supported, the ASR and NMI counters are derived from the SEL
+// and resetting them would clear the whole event log
+func (s *SupermicroX10) ResetRecoveryCounters() (status bool, err error) {
+	return status, errors.ErrFeatureUnavailable
+}
+
+// GetPowerRestorePolicy returns the power state the machine goes to when the AC power comes back
+func (s *SupermicroX10) GetPowerRestorePolicy() (policy devices.PowerRestorePolicy, err error) {
+	i, err := ipmi.New(s.username, s.password, s.ip)
+	if err != nil {
+		return policy, err
+	}
+	policy, err = i.GetPowerRestorePolicy()
+	return policy, err
+}
+
+// SetPowerRestorePolicy defines the power state the machine goes to when the AC power comes back
+func (s *SupermicroX10) SetPowerRestorePolicy(policy devices.PowerRestorePolicy) (status bool, err error) {
+	i, err := ipmi.New(s.username, s.password, s.ip)
+	if err != nil {
+		return status, err
+	}
+	status, err = i.SetPowerRestorePolicy(policy)
+	return status, err
+}
+
+// ChassisIntrusion reads the intrusion sensor using ipmi, errors.ErrFeatureUnavailable
+// is returned when none is fitted
+func (s *SupermicroX10) ChassisIntrusion() (status devices.IntrusionStatus, err error) {
+	i, err := ipmi.New(s.username, s.password, s.ip)
+	if err != nil {
+		return devices.IntrusionUnknown, err
+	}
+
+	return i.ChassisIntrusion()
+}
+
+// SOL opens the serial over lan console of the machine using ipmi, it's closed when ctx is done.
+// The console output is copied to record when given. A console in use by another session is
+// reported as *errors.SOLBusyError, force disconnects that session instead
+func (s *SupermicroX10) SOL(ctx context.Context, record io.Writer, force bool) (console io.ReadWriteCloser, err error) {
+	i, err := ipmi.New(s.username, s.password, s.ip)
+	if err != nil {
+		return console, err
+	}
+
+	return i.SOL(ctx, record, force)
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/bmc-toolbox/bmclib/devices"
 	"github.com/bmc-toolbox/bmclib/errors"
+	"github.com/bmc-toolbox/bmclib/internal/helper"
 	"github.com/bmc-toolbox/bmclib/internal/httpclient"
 
 	"github.com/bmc-toolbox/bmclib/providers/supermicro"
@@ -36,6 +37,11 @@ type SupermicroX10 struct {
 	serial     string
 }
 
+var (
+	_ devices.PowerController = (*SupermicroX10)(nil)
+	_ devices.Inventory       = (*SupermicroX10)(nil)
+)
+
 // New returns a new SupermicroX10 instance ready to be used
 func New(ip string, username string, password string) (sm *SupermicroX10, err error) {
 	return &SupermicroX10{ip: ip, username: username, password: password}, err
@@ -194,6 +200,26 @@ func (s *SupermicroX10) Serial() (serial string, err error) {
 	return strings.ToLower(serial), err
 }
 
+// ServiceTag returns the serial number of the chassis, or the product when the chassis one isn't set,
+// upper case as printed on the asset tag. Unlike Serial it doesn't carry the board serial
+func (s *SupermicroX10) ServiceTag() (serviceTag string, err error) {
+	ipmi, err := s.query("FRU_INFO.XML=(0,0)")
+	if err != nil {
+		return serviceTag, err
+	}
+
+	if ipmi.FruInfo == nil || ipmi.FruInfo.Chassis == nil {
+		return serviceTag, errors.ErrInvalidSerial
+	}
+
+	serviceTag = strings.TrimSpace(ipmi.FruInfo.Chassis.SerialNum)
+	if !strings.HasPrefix(serviceTag, "S") && ipmi.FruInfo.Product != nil {
+		serviceTag = strings.TrimSpace(ipmi.FruInfo.Product.SerialNum)
+	}
+
+	return strings.ToUpper(serviceTag), err
+}
+
 // BmcType returns just Model id string - supermicrox10
 func (s *SupermicroX10) BmcType() (model string) {
 	return BmcType
@@ -441,6 +467,11 @@ func (s *SupermicroX10) Vendor() (vendor string) {
 	return supermicro.VendorID
 }
 
+// SupportedActions returns the names of the action methods implemented by this provider
+func (s *SupermicroX10) SupportedActions() (actions []string) {
+	return helper.SupportedActions(s)
+}
+
 // ServerSnapshot do best effort to populate the server data and returns a blade or discrete
 func (s *SupermicroX10) ServerSnapshot() (server interface{}, err error) {
 	if isBlade, _ := s.IsBlade(); isBlade {
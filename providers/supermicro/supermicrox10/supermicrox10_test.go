@@ -498,3 +498,23 @@ func TestUpdateCredentials(t *testing.T) {
 
 	tearDown()
 }
+
+func TestServiceTag(t *testing.T) {
+	expectedAnswer := "A19627226A05569"
+
+	bmc, err := setup()
+	if err != nil {
+		t.Fatalf("Found errors during the test setup %v", err)
+	}
+
+	answer, err := bmc.ServiceTag()
+	if err != nil {
+		t.Fatalf("Found errors calling bmc.ServiceTag %v", err)
+	}
+
+	if answer != expectedAnswer {
+		t.Errorf("Expected answer %v: found %v", expectedAnswer, answer)
+	}
+
+	tearDown()
+}